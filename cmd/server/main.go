@@ -1,22 +1,55 @@
 package main
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
 	"github.com/dvl-mukesh/go-workshop/internal/comment"
 	"github.com/dvl-mukesh/go-workshop/internal/config"
+	"github.com/dvl-mukesh/go-workshop/internal/contentfilter"
 	"github.com/dvl-mukesh/go-workshop/internal/database"
+	"github.com/dvl-mukesh/go-workshop/internal/database/memory"
+	"github.com/dvl-mukesh/go-workshop/internal/health"
+	"github.com/dvl-mukesh/go-workshop/internal/logging"
+	"github.com/dvl-mukesh/go-workshop/internal/metrics"
 	"github.com/dvl-mukesh/go-workshop/internal/middleware"
+	"github.com/dvl-mukesh/go-workshop/internal/outbox"
+	"github.com/dvl-mukesh/go-workshop/internal/ratelimit"
+	"github.com/dvl-mukesh/go-workshop/internal/request"
+	"github.com/dvl-mukesh/go-workshop/internal/tracing"
 	transportHTTP "github.com/dvl-mukesh/go-workshop/internal/transport/http"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"gorm.io/gorm"
 )
 
+// commentStore is a comment store whose outbox the relay drains.
+type commentStore interface {
+	comment.Store
+	outbox.Store
+}
+
 type App struct {
+	db      *gorm.DB
+	health  *health.Registry
+	metrics *metrics.Registry
+	tracer  *tracing.Tracer
+	// jobs tracks background work that must finish before the database
+	// pool is closed.
+	jobs sync.WaitGroup
 }
 
 func (app *App) Run() error {
-	log.Println("Settting up our APP")
+	app.health = health.NewRegistry()
+	app.metrics = metrics.NewRegistry()
 
 	var envVars config.Environment
 
@@ -24,45 +57,394 @@ func (app *App) Run() error {
 		return err
 	}
 
-	db, err := database.NewDatabase(&envVars)
+	if err := envVars.Validate(); err != nil {
+		return err
+	}
+
+	logger, err := logging.New(os.Stdout, envVars.LogLevel, envVars.LogFormat)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	slog.Info("Setting up our app")
+
+	if err := app.newTracer(&envVars); err != nil {
+		return err
+	}
+	// Covers the early exits; a graceful shutdown flushes sooner, and
+	// flushing again does nothing.
+	defer app.flushTraces()
+
+	store, err := app.newCommentStore(&envVars)
 	if err != nil {
 		return err
 	}
 
-	err = database.MigrateDB(db)
+	commentOpts := []comment.Option{
+		comment.WithMaxDepth(envVars.CommentMaxDepth),
+		comment.WithMaxPageSize(envVars.CommentMaxPageSize),
+		comment.WithDefaultStatus(comment.Status(envVars.CommentDefaultStatus)),
+		comment.WithReportThreshold(envVars.CommentReportThreshold),
+		comment.WithReactions(comment.ParseReactions(envVars.CommentReactions)...),
+	}
+	filters, err := newContentFilters(&envVars, store)
 	if err != nil {
 		return err
 	}
+	commentOpts = append(commentOpts, comment.WithFilters(filters...))
+	if !envVars.AuthDisabled {
+		commentOpts = append(commentOpts, comment.WithPolicy(&comment.Policy{
+			EditWindow: time.Duration(envVars.CommentEditWindow) * time.Minute,
+		}))
+	}
 
-	commentService := comment.NewService(db)
-	handler := transportHTTP.NewHandler(commentService)
+	hooks := []comment.Hook{metrics.NewOperations(app.metrics, "comment_service").Hook}
+	if app.tracer != nil {
+		hooks = append(hooks, app.tracer.Hook("comment.Service"))
+	}
+	commentService := comment.Instrument(comment.NewService(store, commentOpts...), hooks...)
+	handler := transportHTTP.NewHandler(commentService, app.health)
+	handler.Metrics = app.metrics
+	handler.MaxBodyBytes = int64(envVars.MaxBodyBytes)
 
 	handler.SetupRoutes()
-	log.Printf("Starting API server on PORT %s\n", envVars.Port)
-	log.Println("Server Started...")
+	slog.Info("Starting API server", "port", envVars.Port)
 
-	stack := middleware.CreateStack(
-		middleware.Logging,
-	)
+	middlewares, err := app.middlewares(&envVars, handler)
+	if err != nil {
+		return err
+	}
+
+	stack := middleware.CreateStack(middlewares...)
 
 	server := http.Server{
 		Addr:    ":" + envVars.Port,
 		Handler: stack(handler.Router),
 	}
 
-	if err := server.ListenAndServe(); err != nil {
-		log.Println("Failed to setup server")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if envVars.CommentRetentionDays > 0 {
+		app.startRetention(ctx, &envVars, store)
+	}
+	if err := app.startRelay(ctx, &envVars, store); err != nil {
+		stop()
+		app.jobs.Wait()
+		app.closeDB()
+		return err
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	handler.SetReady(true)
+
+	select {
+	case err := <-serverErr:
+		slog.Error("Failed to setup server")
+		stop()
+		app.jobs.Wait()
+		app.closeDB()
 		return err
+	case <-ctx.Done():
+		stop()
+	}
+
+	return app.shutdown(&server, handler, envVars)
+}
+
+// middlewares builds the middleware stack, outermost first.
+func (app *App) middlewares(envVars *config.Environment, handler *transportHTTP.Handler) ([]middleware.Middleware, error) {
+	trusted, err := request.ParseTrustedProxies(envVars.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	middlewares := []middleware.Middleware{
+		middleware.RequestID,
+		middleware.ClientIP(trusted),
+	}
+
+	if app.tracer != nil {
+		middlewares = append(middlewares, middleware.Tracing(app.tracer, handler.RoutePattern))
+	}
+
+	middlewares = append(middlewares,
+		middleware.Logging,
+		middleware.Metrics(app.metrics, handler.RoutePattern),
+	)
+
+	var limitStore ratelimit.Store
+	window := time.Duration(envVars.RateLimitWindow) * time.Second
+	if envVars.RateLimitDisabled {
+		slog.Warn("Rate limiting is disabled")
+	} else {
+		limitStore = memory.NewRateLimitStore()
+		if envVars.RateLimitStore == config.RateLimitStoreDatabase {
+			limitStore = database.NewRateLimitStore(app.db)
+		}
+		// Counted before authentication so failed logins are throttled.
+		middlewares = append(middlewares, middleware.RateLimitIP(limitStore,
+			ratelimit.Limit{Requests: envVars.RateLimitIP, Window: window},
+		))
+	}
+
+	if envVars.AuthDisabled {
+		slog.Warn("Authentication is disabled")
+	} else {
+		authenticator, err := auth.NewAuthenticator(envVars)
+		if err != nil {
+			return nil, err
+		}
+		middlewares = append(middlewares, middleware.Authenticate(authenticator))
+	}
+
+	if limitStore != nil {
+		middlewares = append(middlewares, middleware.RateLimit(limitStore,
+			ratelimit.Limit{Requests: envVars.RateLimitReads, Window: window},
+			ratelimit.Limit{Requests: envVars.RateLimitWrites, Window: window},
+		))
+	}
+
+	return middlewares, nil
+}
+
+// shutdown stops the server in phases: it first reports not ready so load
+// balancers stop routing new traffic here, waits for them to notice, drains
+// in-flight requests within the shutdown timeout and finally flushes traces
+// and closes the database pool.
+func (app *App) shutdown(server *http.Server, handler *transportHTTP.Handler, envVars config.Environment) error {
+	slog.Info("Shutdown signal received, marking server as not ready")
+	handler.SetReady(false)
+
+	if delay := time.Duration(envVars.ShutdownDelay) * time.Second; delay > 0 {
+		slog.Info("Waiting for load balancers to stop sending traffic", "delay", delay)
+		time.Sleep(delay)
+	}
+
+	timeout := time.Duration(envVars.ShutdownTimeout) * time.Second
+	slog.Info("Draining in-flight requests", "timeout", timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		slog.Warn("Graceful shutdown timed out, closing remaining connections")
+		server.Close()
+	} else {
+		slog.Info("All requests drained")
 	}
 
+	app.flushTraces()
+	app.jobs.Wait()
+	app.closeDB()
+	slog.Info("Server stopped")
+
+	return err
+}
+
+func (app *App) closeDB() {
+	if app.db == nil {
+		return
+	}
+
+	sqlDb, err := app.db.DB()
+	if err != nil {
+		slog.Error("Failed to get database pool", "err", err)
+		return
+	}
+
+	slog.Info("Closing database connections")
+	if err := sqlDb.Close(); err != nil {
+		slog.Error("Failed to close database connections", "err", err)
+	}
+}
+
+// newTracer sets up the configured trace exporter. Tracing stays off when no
+// exporter is configured.
+func (app *App) newTracer(envVars *config.Environment) error {
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch envVars.TracingExporter {
+	case "":
+		return nil
+	case config.TracingExporterOTLP:
+		exporter, err = tracing.NewOTLPExporter(context.Background(), envVars.TracingOTLPEndpoint)
+	case config.TracingExporterStdout:
+		exporter, err = tracing.NewWriterExporter(os.Stdout)
+	case config.TracingExporterFile:
+		exporter, err = tracing.NewFileExporter(envVars.TracingFile)
+	}
+	if err != nil {
+		return fmt.Errorf("creating trace exporter: %w", err)
+	}
+
+	slog.Info("Tracing enabled", "exporter", envVars.TracingExporter, "sample_percent", envVars.TracingSamplePercent)
+	app.tracer = tracing.NewTracer(exporter, envVars.TracingServiceName, float64(envVars.TracingSamplePercent)/100)
 	return nil
 }
 
+// flushTraces exports buffered spans before exit, with its own deadline
+// since whatever was shutting down may have used up its context.
+func (app *App) flushTraces() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := app.tracer.Shutdown(ctx); err != nil {
+		slog.Error("Failed to flush traces", "err", err)
+	}
+}
+
+func (app *App) newCommentStore(envVars *config.Environment) (commentStore, error) {
+	if envVars.DbDriver == config.DriverMemory {
+		slog.Info("Using in-memory comment store")
+		return memory.NewCommentStore(), nil
+	}
+
+	db, err := database.NewDatabase(envVars)
+	if err != nil {
+		return nil, err
+	}
+
+	app.db = db
+	app.health.Register("database", database.HealthCheck(db))
+
+	if sqlDb, err := db.DB(); err == nil {
+		metrics.RegisterDBStats(app.metrics, sqlDb)
+	}
+
+	if app.tracer != nil {
+		if err := database.RegisterTracing(db, app.tracer); err != nil {
+			app.closeDB()
+			return nil, err
+		}
+	}
+
+	if err := database.MigrateDB(db); err != nil {
+		app.closeDB()
+		return nil, err
+	}
+
+	return database.NewCommentStore(db), nil
+}
+
+// startRetention purges long-deleted comments in the background until ctx
+// is done.
+func (app *App) startRetention(ctx context.Context, envVars *config.Environment, store comment.Store) {
+	retention := &comment.Retention{
+		Store:    store,
+		MaxAge:   time.Duration(envVars.CommentRetentionDays) * 24 * time.Hour,
+		Interval: time.Duration(envVars.CommentRetentionInterval) * time.Minute,
+	}
+	slog.Info("Purging deleted comments", "after_days", envVars.CommentRetentionDays, "interval", retention.Interval)
+
+	app.jobs.Add(1)
+	go func() {
+		defer app.jobs.Done()
+		retention.Run(ctx)
+	}()
+}
+
+// startRelay delivers outbox events to the configured sinks in the
+// background until ctx is done. Without sinks the relay only prunes, so the
+// outbox doesn't grow without bound.
+func (app *App) startRelay(ctx context.Context, envVars *config.Environment, store outbox.Store) error {
+	sinkNames := envVars.OutboxSinkList()
+	var sinks []outbox.Sink
+	var file *outbox.FileSink
+	for _, name := range sinkNames {
+		switch name {
+		case config.OutboxSinkLog:
+			sinks = append(sinks, outbox.LogSink{})
+		case config.OutboxSinkWebhook:
+			sinks = append(sinks, &outbox.WebhookSink{
+				URL:    envVars.OutboxWebhookURL,
+				Secret: envVars.OutboxWebhookSecret,
+			})
+		case config.OutboxSinkFile:
+			f, err := outbox.NewFileSink(envVars.OutboxFile)
+			if err != nil {
+				return fmt.Errorf("opening outbox file: %w", err)
+			}
+			file = f
+			sinks = append(sinks, f)
+		}
+	}
+
+	relay := &outbox.Relay{
+		Store:    store,
+		Sinks:    sinks,
+		Interval: time.Duration(envVars.OutboxInterval) * time.Second,
+	}
+	if len(sinks) == 0 {
+		slog.Info("No outbox sinks, pruning undelivered events", "after", outbox.DefaultRetain)
+	} else {
+		slog.Info("Relaying outbox events", "sinks", sinkNames, "interval", relay.Interval)
+	}
+
+	app.jobs.Add(1)
+	go func() {
+		defer app.jobs.Done()
+		relay.Run(ctx)
+		if file != nil {
+			if err := file.Close(); err != nil {
+				slog.Error("Failed to close outbox file", "err", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// newContentFilters builds the filters new comments go through, in the
+// order cheapest first.
+func newContentFilters(envVars *config.Environment, store comment.Store) ([]comment.Filter, error) {
+	var filters []comment.Filter
+
+	if envVars.FilterBannedWordsFile != "" {
+		entries, err := contentfilter.LoadList(envVars.FilterBannedWordsFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading banned words: %w", err)
+		}
+		words, err := contentfilter.NewWords(entries, comment.Verdict(envVars.FilterBannedVerdict))
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, words)
+	}
+	if domains := contentfilter.ParseDomains(envVars.FilterBlockedDomains); len(domains) > 0 {
+		filters = append(filters, contentfilter.DomainBlocklist{Domains: domains})
+	}
+	if envVars.FilterMaxLinks > 0 {
+		filters = append(filters, contentfilter.LinkLimit{Max: envVars.FilterMaxLinks})
+	}
+	if envVars.FilterFloodMax > 0 || envVars.FilterDuplicates {
+		filters = append(filters, &contentfilter.Flood{
+			Store:      store,
+			Window:     time.Duration(envVars.FilterFloodWindow) * time.Second,
+			Max:        envVars.FilterFloodMax,
+			Duplicates: envVars.FilterDuplicates,
+		})
+	}
+
+	return filters, nil
+}
+
 func main() {
-	log.Println("GO REST API Course")
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			slog.Error("Migration failed", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	app := App{}
 	if err := app.Run(); err != nil {
-		log.Println("Error starting up REST API")
-		log.Fatal(err)
+		slog.Error("Error starting up REST API", "err", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+	"github.com/dvl-mukesh/go-workshop/internal/config"
+	"github.com/dvl-mukesh/go-workshop/internal/database"
+)
+
+const migrateUsage = "usage: migrate up|down|status|to <version>"
+
+// runMigrate implements the `migrate` subcommand.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	var envVars config.Environment
+
+	if err := dvlutil.ReadEnvVars(&envVars); err != nil {
+		return err
+	}
+
+	if err := envVars.Validate(); err != nil {
+		return err
+	}
+
+	if envVars.DbDriver == config.DriverMemory {
+		return errors.New("the memory driver has no schema to migrate")
+	}
+
+	db, err := database.NewDatabase(&envVars)
+	if err != nil {
+		return err
+	}
+
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		return m.Up()
+	case "down":
+		return m.Down()
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return m.To(version)
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state, appliedAt := "pending", ""
+			if s.Applied {
+				state = "applied"
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				state += " (modified)"
+			}
+			if s.Unknown {
+				state += " (unknown)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
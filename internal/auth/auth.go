@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/config"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+var (
+	ErrInvalidAPIKey    = errors.New("invalid API key")
+	ErrNoCredentials    = errors.New("no credentials configured")
+	ErrMalformedAPIKeys = errors.New("malformed AUTH_API_KEYS entry, expected key=subject[:role|role]")
+)
+
+type apiKey struct {
+	hash      [sha256.Size]byte
+	principal Principal
+}
+
+// Authenticator validates the credentials presented with a request.
+type Authenticator struct {
+	jwt     *jwtVerifier
+	apiKeys []apiKey
+}
+
+func NewAuthenticator(env *config.Environment) (*Authenticator, error) {
+	keys := newKeySet()
+	if env.AuthJWTSecret != "" {
+		keys.hmac[""] = []byte(env.AuthJWTSecret)
+	}
+	if env.AuthJWKSFile != "" {
+		if err := loadJWKS(env.AuthJWKSFile, keys); err != nil {
+			return nil, err
+		}
+	}
+
+	a := &Authenticator{}
+	if len(keys.hmac) > 0 || len(keys.rsa) > 0 {
+		a.jwt = &jwtVerifier{
+			keys:     keys,
+			issuer:   env.AuthJWTIssuer,
+			audience: env.AuthJWTAudience,
+			now:      time.Now,
+		}
+	}
+
+	apiKeys, err := parseAPIKeys(env.AuthAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+	a.apiKeys = apiKeys
+
+	if a.jwt == nil && len(a.apiKeys) == 0 {
+		return nil, fmt.Errorf("%w: set AUTH_JWT_SECRET, AUTH_JWKS_FILE or AUTH_API_KEYS, or AUTH_DISABLED=true", ErrNoCredentials)
+	}
+	return a, nil
+}
+
+// parseAPIKeys reads a comma separated list of key=subject entries, each
+// optionally followed by :role|role.
+func parseAPIKeys(s string) ([]apiKey, error) {
+	var keys []apiKey
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, rest, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return nil, ErrMalformedAPIKeys
+		}
+		subject, roles, _ := strings.Cut(rest, ":")
+		if subject == "" {
+			return nil, ErrMalformedAPIKeys
+		}
+
+		p := Principal{Subject: subject, Method: MethodAPIKey}
+		if roles != "" {
+			p.Roles = strings.Split(roles, "|")
+		}
+		keys = append(keys, apiKey{hash: sha256.Sum256([]byte(key)), principal: p})
+	}
+	return keys, nil
+}
+
+// Authenticate checks the bearer token or API key on r. The boolean result
+// reports whether the request carried credentials at all.
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, bool, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		p, err := a.checkAPIKey(key)
+		return p, true, err
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return Principal{}, false, nil
+	}
+	if a.jwt == nil {
+		return Principal{}, true, ErrUnknownKey
+	}
+
+	p, err := a.jwt.verify(strings.TrimSpace(token))
+	return p, true, err
+}
+
+func (a *Authenticator) checkAPIKey(key string) (Principal, error) {
+	hash := sha256.Sum256([]byte(key))
+	for _, k := range a.apiKeys {
+		if subtle.ConstantTimeCompare(hash[:], k.hash[:]) == 1 {
+			return k.principal, nil
+		}
+	}
+	return Principal{}, ErrInvalidAPIKey
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/config"
+)
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func signHS256(t *testing.T, secret string, claims map[string]any) string {
+	t.Helper()
+
+	unsigned := encodeSegment(t, map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+
+	unsigned := encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func authenticate(t *testing.T, a *Authenticator, header, value string) (Principal, bool, error) {
+	t.Helper()
+
+	r := httptest.NewRequest("POST", "/v1/api/comment", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+	return a.Authenticate(r)
+}
+
+func TestHS256Token(t *testing.T) {
+	a, err := NewAuthenticator(&config.Environment{AuthJWTSecret: "s3cret", AuthJWTIssuer: "issuer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	token := signHS256(t, "s3cret", map[string]any{"sub": "alice", "iss": "issuer", "exp": exp, "roles": []string{"moderator"}})
+
+	p, present, err := authenticate(t, a, "Authorization", "Bearer "+token)
+	if err != nil || !present {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if p.Subject != "alice" || !p.HasRole("moderator") || p.Method != MethodJWT {
+		t.Errorf("unexpected principal %+v", p)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"wrong secret", signHS256(t, "other", map[string]any{"sub": "alice", "iss": "issuer", "exp": exp}), ErrUnknownKey},
+		{"expired", signHS256(t, "s3cret", map[string]any{"sub": "alice", "iss": "issuer", "exp": time.Now().Add(-time.Hour).Unix()}), ErrTokenExpired},
+		{"wrong issuer", signHS256(t, "s3cret", map[string]any{"sub": "alice", "iss": "evil", "exp": exp}), ErrInvalidToken},
+		{"missing exp", signHS256(t, "s3cret", map[string]any{"sub": "alice", "iss": "issuer"}), ErrInvalidToken},
+		{"alg none", encodeSegment(t, map[string]string{"alg": "none"}) + "." + encodeSegment(t, map[string]any{"sub": "alice", "exp": exp}) + ".", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := authenticate(t, a, "Authorization", "Bearer "+tt.token); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRS256TokenFromJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks, _ := json.Marshal(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := NewAuthenticator(&config.Environment{AuthJWKSFile: path, AuthJWTAudience: "comments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := map[string]any{"sub": "bob", "aud": []string{"comments"}, "exp": time.Now().Add(time.Minute).Unix()}
+	if p, _, err := authenticate(t, a, "Authorization", "Bearer "+signRS256(t, key, "k1", claims)); err != nil || p.Subject != "bob" {
+		t.Errorf("valid RS256 token: principal %+v, err %v", p, err)
+	}
+	if _, _, err := authenticate(t, a, "Authorization", "Bearer "+signRS256(t, key, "k2", claims)); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown kid: got %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	a, err := NewAuthenticator(&config.Environment{AuthAPIKeys: "k-1=svc:admin|moderator, k-2=bot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, present, err := authenticate(t, a, APIKeyHeader, "k-1")
+	if err != nil || !present || p.Subject != "svc" || !p.HasRole("admin") {
+		t.Errorf("k-1: principal %+v, err %v", p, err)
+	}
+	if _, _, err := authenticate(t, a, APIKeyHeader, "nope"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("unknown key: got %v, want ErrInvalidAPIKey", err)
+	}
+	if _, present, err := authenticate(t, a, "", ""); present || err != nil {
+		t.Errorf("no credentials: present %v, err %v", present, err)
+	}
+
+	if _, err := NewAuthenticator(&config.Environment{AuthAPIKeys: "missing-subject="}); !errors.Is(err, ErrMalformedAPIKeys) {
+		t.Errorf("malformed entry: got %v", err)
+	}
+	if _, err := NewAuthenticator(&config.Environment{}); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("no configuration: got %v", err)
+	}
+}
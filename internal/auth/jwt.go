@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// clockSkew is the leeway allowed when checking exp and nbf.
+const clockSkew = 30 * time.Second
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+	ErrUnknownKey   = errors.New("no key to verify token")
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// audience accepts both the string and array forms of the aud claim.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+type claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	Roles     []string `json:"roles"`
+}
+
+// jwtVerifier checks HS256 and RS256 signed JWTs against the configured keys
+// and validates the registered claims.
+type jwtVerifier struct {
+	keys     *keySet
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+func (v *jwtVerifier) verify(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, ErrInvalidToken
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	if err := v.verifySignature(header, signed, sig); err != nil {
+		return Principal{}, err
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+	if err := v.validate(c); err != nil {
+		return Principal{}, err
+	}
+
+	return Principal{
+		Subject: c.Subject,
+		Roles:   c.Roles,
+		Method:  MethodJWT,
+	}, nil
+}
+
+func (v *jwtVerifier) verifySignature(header jwtHeader, signed, sig []byte) error {
+	digest := sha256.Sum256(signed)
+
+	switch header.Alg {
+	case "HS256":
+		for _, secret := range candidates(v.keys.hmac, header.Kid) {
+			mac := hmac.New(sha256.New, secret)
+			mac.Write(signed)
+			if hmac.Equal(mac.Sum(nil), sig) {
+				return nil
+			}
+		}
+	case "RS256":
+		for _, pub := range candidates(v.keys.rsa, header.Kid) {
+			if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil {
+				return nil
+			}
+		}
+	default:
+		return ErrInvalidToken
+	}
+	return ErrUnknownKey
+}
+
+// candidates returns the key with the given kid, or every key when the token
+// doesn't name one. A key configured without a kid (the shared secret) also
+// matches tokens naming an unknown kid.
+func candidates[K any](keys map[string]K, kid string) []K {
+	if kid != "" {
+		if k, ok := keys[kid]; ok {
+			return []K{k}
+		}
+		if k, ok := keys[""]; ok {
+			return []K{k}
+		}
+		return nil
+	}
+
+	all := make([]K, 0, len(keys))
+	for _, k := range keys {
+		all = append(all, k)
+	}
+	return all
+}
+
+func (v *jwtVerifier) validate(c claims) error {
+	now := v.now()
+
+	if c.Subject == "" || c.ExpiresAt == nil {
+		return ErrInvalidToken
+	}
+	if now.After(time.Unix(*c.ExpiresAt, 0).Add(clockSkew)) {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != nil && now.Add(clockSkew).Before(time.Unix(*c.NotBefore, 0)) {
+		return ErrInvalidToken
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return ErrInvalidToken
+	}
+	if v.audience != "" && !slices.Contains(c.Audience, v.audience) {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,35 @@
+package comment
+
+import "context"
+
+// ArticleComments is one page of the comments posted on an article, along
+// with the total number of comments on it.
+type ArticleComments struct {
+	Slug  string `json:"slug"`
+	Count int64  `json:"count"`
+	Page
+}
+
+func (s *Service) CountCommentsBySlug(ctx context.Context, slug string) (int64, error) {
+	return s.Store.CountCommentsBySlug(ctx, slug)
+}
+
+func (s *Service) GetArticleComments(ctx context.Context, slug string, opts ListOptions) (ArticleComments, error) {
+	opts.Slug = slug
+
+	page, err := s.ListComments(ctx, opts)
+	if err != nil {
+		return ArticleComments{}, err
+	}
+
+	count, err := s.CountCommentsBySlug(ctx, slug)
+	if err != nil {
+		return ArticleComments{}, err
+	}
+
+	return ArticleComments{
+		Slug:  slug,
+		Count: count,
+		Page:  page,
+	}, nil
+}
@@ -0,0 +1,229 @@
+package comment
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+	"github.com/dvl-mukesh/go-workshop/internal/request"
+)
+
+// AuditAction is the kind of mutation an audit entry records.
+type AuditAction string
+
+const (
+	AuditCreate   AuditAction = "create"
+	AuditUpdate   AuditAction = "update"
+	AuditDelete   AuditAction = "delete"
+	AuditModerate AuditAction = "moderate"
+	AuditRestore  AuditAction = "restore"
+	AuditPurge    AuditAction = "purge"
+)
+
+var auditActions = []AuditAction{AuditCreate, AuditUpdate, AuditDelete, AuditModerate, AuditRestore, AuditPurge}
+
+// RetentionActor is the actor recorded for comments purged by Retention.
+const RetentionActor = "system:retention"
+
+// auditExportBatch is how many entries ExportAuditLog reads at a time.
+const auditExportBatch = 500
+
+var ErrInvalidAuditAction = Validation("invalid_audit_action", "invalid audit action, expected create, update, delete, moderate, restore or purge")
+
+// AuditEntry records one mutation of a comment: who made it, from where,
+// and the comment before and after. Entries are only ever appended, in the
+// same transaction as the change they record.
+//
+// Snapshots leave out the body, which only lives on in the comment and its
+// revisions, so purging a comment removes its content for good while the
+// entries about it are kept.
+type AuditEntry struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	Action    AuditAction `json:"action" gorm:"index"`
+	CommentID uint        `json:"comment_id" gorm:"index"`
+	// Actor is the authenticated subject, empty for anonymous callers.
+	Actor     string    `json:"actor" gorm:"index"`
+	Reason    string    `json:"reason,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	ClientIP  string    `json:"client_ip,omitempty"`
+	Before    *Comment  `json:"before,omitempty" gorm:"serializer:json"`
+	After     *Comment  `json:"after,omitempty" gorm:"serializer:json"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
+
+// AuditOptions filters the audit log. Zero values match everything.
+type AuditOptions struct {
+	CommentID uint
+	Actor     string
+	Action    AuditAction
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+	Cursor    string
+}
+
+// AuditQuery is a single keyset scan over the audit log.
+type AuditQuery struct {
+	CommentID uint
+	Actor     string
+	Action    AuditAction
+	Since     time.Time
+	Until     time.Time
+	Desc      bool
+	// AfterID, when set, only matches entries strictly after it in the scan
+	// order.
+	AfterID uint
+	Limit   int
+}
+
+type AuditPage struct {
+	Items []AuditEntry `json:"items"`
+	Page  PageInfo     `json:"page"`
+}
+
+// auditEntry starts the entry for a mutation made by the caller in ctx. The
+// store completes it with Snapshot and appends it along with the change.
+func auditEntry(ctx context.Context, action AuditAction, reason string) AuditEntry {
+	entry := AuditEntry{
+		Action:    action,
+		Reason:    reason,
+		RequestID: request.IDFromContext(ctx),
+		ClientIP:  request.ClientIPFromContext(ctx),
+		CreatedAt: time.Now(),
+	}
+	if actor, ok := auth.PrincipalFromContext(ctx); ok {
+		entry.Actor = actor.Subject
+	}
+	return entry
+}
+
+// Snapshot returns e completed for the comment with the given ID as it was
+// before and after the change, without their bodies.
+func (e AuditEntry) Snapshot(ID uint, before, after *Comment) AuditEntry {
+	e.CommentID = ID
+	e.Before = withoutBody(before)
+	e.After = withoutBody(after)
+	return e
+}
+
+func withoutBody(c *Comment) *Comment {
+	if c == nil {
+		return nil
+	}
+	snapshot := *c
+	snapshot.Body = ""
+	return &snapshot
+}
+
+func (opts AuditOptions) query() (AuditQuery, error) {
+	if opts.Action != "" && !slices.Contains(auditActions, opts.Action) {
+		return AuditQuery{}, ErrInvalidAuditAction
+	}
+	return AuditQuery{
+		CommentID: opts.CommentID,
+		Actor:     opts.Actor,
+		Action:    opts.Action,
+		Since:     opts.Since,
+		Until:     opts.Until,
+	}, nil
+}
+
+// auditCursor is the position encoded into audit log next tokens.
+type auditCursor struct {
+	ID uint `json:"id"`
+}
+
+func (c auditCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeAuditCursor(s string) (auditCursor, error) {
+	var c auditCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == 0 {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// GetAuditLog returns one page of audit entries matching opts, newest
+// first, for admins.
+func (s *Service) GetAuditLog(ctx context.Context, opts AuditOptions) (AuditPage, error) {
+	if err := s.authorize(ctx, ActionViewAudit, Comment{}); err != nil {
+		return AuditPage{}, err
+	}
+
+	q, err := opts.query()
+	if err != nil {
+		return AuditPage{}, err
+	}
+	if opts.Cursor != "" {
+		c, err := decodeAuditCursor(opts.Cursor)
+		if err != nil {
+			return AuditPage{}, err
+		}
+		q.AfterID = c.ID
+	}
+	q.Desc = true
+	q.Limit = min(max(opts.Limit, 0), s.MaxPageSize)
+	if q.Limit == 0 {
+		q.Limit = DefaultPageSize
+	}
+	limit := q.Limit
+	q.Limit++
+
+	entries, err := s.Store.ListAudit(ctx, q)
+	if err != nil {
+		return AuditPage{}, err
+	}
+
+	page := AuditPage{Items: entries, Page: PageInfo{Limit: limit}}
+	if len(entries) > limit {
+		page.Items = entries[:limit]
+		page.Page.NextCursor = auditCursor{ID: page.Items[limit-1].ID}.encode()
+	}
+	return page, nil
+}
+
+// ExportAuditLog writes every audit entry matching opts to w as JSON Lines,
+// oldest first, for admins. opts.Limit and opts.Cursor are ignored.
+func (s *Service) ExportAuditLog(ctx context.Context, opts AuditOptions, w io.Writer) error {
+	if err := s.authorize(ctx, ActionViewAudit, Comment{}); err != nil {
+		return err
+	}
+
+	q, err := opts.query()
+	if err != nil {
+		return err
+	}
+	q.Limit = auditExportBatch
+
+	enc := json.NewEncoder(w)
+	for {
+		entries, err := s.Store.ListAudit(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		if len(entries) < q.Limit {
+			return nil
+		}
+		q.AfterID = entries[len(entries)-1].ID
+	}
+}
@@ -1,83 +1,294 @@
 package comment
 
-import "gorm.io/gorm"
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+	"gorm.io/gorm"
+)
+
+const DefaultMaxDepth = 10
+
+var (
+	ErrParentNotFound   = NotFound("parent_not_found", "parent comment not found")
+	ErrMaxDepthExceeded = Validation("max_depth_exceeded", "maximum thread depth exceeded")
+	ErrSlugMismatch     = Validation("slug_mismatch", "reply must be on the same article as its parent")
+)
 
 type Service struct {
-	DB *gorm.DB
+	Store           Store
+	MaxDepth        int
+	MaxPageSize     int
+	Policy          *Policy
+	DefaultStatus   Status
+	ReportThreshold int
+	Filters         []Filter
+	Reactions       []string
 }
 
 type Comment struct {
 	gorm.Model
-	Slug   string `json:"slug"`
-	Body   string `json:"body"`
-	Author string `json:"author"`
+	Slug     string `json:"slug" gorm:"index"`
+	Body     string `json:"body"`
+	Author   string `json:"author"`
+	ParentID *uint  `json:"parent_id" gorm:"index"`
+	RootID   *uint  `json:"root_id" gorm:"index"`
+	Depth    int    `json:"depth"`
+
+	Status           Status     `json:"status" gorm:"index;not null;default:approved"`
+	ModerationReason string     `json:"moderation_reason,omitempty"`
+	ModeratedBy      string     `json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty"`
+	ReportCount      int        `json:"report_count"`
+
+	// Vote and reaction totals, kept up to date by the store.
+	Upvotes   int            `json:"upvotes"`
+	Downvotes int            `json:"downvotes"`
+	Score     float64        `json:"score"`
+	Reactions map[string]int `json:"reactions,omitempty" gorm:"serializer:json"`
+
+	// Edit history; the revisions themselves are kept by the store.
+	Edited    bool       `json:"edited"`
+	EditCount int        `json:"edit_count"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
 }
 
 type CommentService interface {
-	GetComment(ID uint) (Comment, error)
-	GetCommentBySlug(slug string) ([]Comment, error)
-	PostComment(comment Comment) (Comment, error)
-	UpdateComment(ID uint, newComment Comment) (Comment, error)
-	DeleteComment(ID uint) error
-	GetAllComments() ([]Comment, error)
+	GetComment(ctx context.Context, ID uint) (Comment, error)
+	GetCommentBySlug(ctx context.Context, slug string) ([]Comment, error)
+	PostComment(ctx context.Context, comment Comment) (Comment, error)
+	ReplyToComment(ctx context.Context, parentID uint, reply Comment) (Comment, error)
+	UpdateComment(ctx context.Context, ID uint, newComment Comment, reason string) (Comment, error)
+	DeleteComment(ctx context.Context, ID uint) error
+	GetAllComments(ctx context.Context) ([]Comment, error)
+	ListComments(ctx context.Context, opts ListOptions) (Page, error)
+	CountCommentsBySlug(ctx context.Context, slug string) (int64, error)
+	GetArticleComments(ctx context.Context, slug string, opts ListOptions) (ArticleComments, error)
+	GetThread(ctx context.Context, ID uint, maxDepth int) (*ThreadNode, error)
+	GetThreadFlat(ctx context.Context, ID uint, maxDepth int) ([]ThreadNode, error)
+	SearchComments(ctx context.Context, opts SearchOptions) (SearchPage, error)
+	ModerateComment(ctx context.Context, ID uint, status Status, reason string) (Comment, error)
+	ReportComment(ctx context.Context, ID uint, reason string) (Report, error)
+	GetReports(ctx context.Context, ID uint) ([]Report, error)
+	ModerationQueue(ctx context.Context, opts ListOptions) (Page, error)
+	GetDecisions(ctx context.Context, ID uint) ([]Decision, error)
+	Vote(ctx context.Context, ID uint, value int) (Comment, error)
+	React(ctx context.Context, ID uint, kind string, add bool) (Comment, error)
+	GetRevisions(ctx context.Context, ID uint) ([]Revision, error)
+	DiffRevisions(ctx context.Context, ID uint, from, to int) (RevisionDiff, error)
+	ListDeletedComments(ctx context.Context, opts ListOptions) (Page, error)
+	RestoreComment(ctx context.Context, ID uint) (Comment, error)
+	PurgeComment(ctx context.Context, ID uint) error
+	GetAuditLog(ctx context.Context, opts AuditOptions) (AuditPage, error)
+	ExportAuditLog(ctx context.Context, opts AuditOptions, w io.Writer) error
 }
 
-func NewService(db *gorm.DB) *Service {
-	return &Service{
-		DB: db,
+type Option func(*Service)
+
+// WithMaxDepth limits how deeply replies can be nested. Non-positive values
+// keep the default.
+func WithMaxDepth(depth int) Option {
+	return func(s *Service) {
+		if depth > 0 {
+			s.MaxDepth = depth
+		}
 	}
 }
 
-func (s *Service) GetComment(ID uint) (Comment, error) {
-	var comment Comment
-	if result := s.DB.First(&comment, ID); result.Error != nil {
-		return Comment{}, result.Error
+func NewService(store Store, opts ...Option) *Service {
+	s := &Service{
+		Store:           store,
+		MaxDepth:        DefaultMaxDepth,
+		MaxPageSize:     MaxPageSize,
+		DefaultStatus:   StatusApproved,
+		ReportThreshold: DefaultReportThreshold,
+		Reactions:       DefaultReactions,
 	}
-	return comment, nil
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *Service) GetCommentBySlug(slug string) ([]Comment, error) {
-	var comments []Comment
+// GetComment returns a comment the caller may see; comments awaiting or
+// failing moderation are reported as not found to everyone else.
+func (s *Service) GetComment(ctx context.Context, ID uint) (Comment, error) {
+	c, err := s.Store.GetComment(ctx, ID)
+	if err != nil {
+		return Comment{}, err
+	}
+	if !s.canSee(ctx, c) {
+		return Comment{}, ErrCommentNotFound
+	}
+	return c, nil
+}
 
-	if result := s.DB.Find(&comments).Where("slug=?", slug); result.Error != nil {
-		return comments, result.Error
+func (s *Service) GetCommentBySlug(ctx context.Context, slug string) ([]Comment, error) {
+	comments, err := s.Store.GetCommentsBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
 	}
-	return comments, nil
+	return s.visible(ctx, comments), nil
 }
 
-func (s *Service) PostComment(comment Comment) (Comment, error) {
-	if result := s.DB.Save(&comment); result.Error != nil {
-		return Comment{}, result.Error
+func (s *Service) PostComment(ctx context.Context, comment Comment) (Comment, error) {
+	if actor, ok := auth.PrincipalFromContext(ctx); ok {
+		comment.Author = actor.Subject
+	}
+	comment.RootID = nil
+	comment.Depth = 0
+	comment.Status = s.DefaultStatus
+	comment.ModerationReason = ""
+	comment.ModeratedBy = ""
+	comment.ModeratedAt = nil
+	comment.ReportCount = 0
+	comment.Upvotes = 0
+	comment.Downvotes = 0
+	comment.Score = 0
+	comment.Reactions = nil
+
+	if comment.ParentID != nil {
+		parent, err := s.GetComment(ctx, *comment.ParentID)
+		if errors.Is(err, ErrCommentNotFound) {
+			return Comment{}, ErrParentNotFound
+		}
+		if err != nil {
+			return Comment{}, err
+		}
+
+		if parent.Depth+1 > s.MaxDepth {
+			return Comment{}, ErrMaxDepthExceeded
+		}
+		// Replies always live on their parent's article.
+		if comment.Slug != "" && comment.Slug != parent.Slug {
+			return Comment{}, ErrSlugMismatch
+		}
+		comment.Slug = parent.Slug
+
+		rootID := parent.ID
+		if parent.RootID != nil {
+			rootID = *parent.RootID
+		}
+		comment.RootID = &rootID
+		comment.Depth = parent.Depth + 1
+	}
+
+	decisions := s.runFilters(ctx, comment)
+	if err := applyDecisions(&comment, decisions); err != nil {
+		s.recordDecisions(ctx, nil, decisions)
+		slog.WarnContext(ctx, "comment rejected", "slug", comment.Slug, "author", comment.Author, "err", err)
+		return Comment{}, err
+	}
+
+	created, err := s.Store.CreateComment(ctx, comment, auditEntry(ctx, AuditCreate, ""))
+	if err != nil {
+		return Comment{}, err
 	}
+	s.recordDecisions(ctx, &created.ID, decisions)
 
-	return comment, nil
+	slog.InfoContext(ctx, "comment created", "id", created.ID, "slug", created.Slug, "author", created.Author, "depth", created.Depth)
+	return created, nil
 }
 
-func (s *Service) UpdateComment(ID uint, newComment Comment) (Comment, error) {
-	comment, err := s.GetComment(ID)
+func (s *Service) ReplyToComment(ctx context.Context, parentID uint, reply Comment) (Comment, error) {
+	reply.ParentID = &parentID
+	return s.PostComment(ctx, reply)
+}
 
+// UpdateComment applies an edit, recording the previous and new content as
+// revisions along with who made it and why. Edits that change nothing are
+// not recorded.
+func (s *Service) UpdateComment(ctx context.Context, ID uint, newComment Comment, reason string) (Comment, error) {
+	existing, err := s.GetComment(ctx, ID)
 	if err != nil {
 		return Comment{}, err
 	}
 
-	if result := s.DB.Model(&comment).Updates(newComment); result.Error != nil {
-		return Comment{}, nil
+	if err := s.authorize(ctx, ActionUpdate, existing); err != nil {
+		return Comment{}, err
+	}
+
+	// Only admins may reassign a comment to someone else. Without a policy
+	// nobody counts as one, so the author stays put.
+	if actor, ok := auth.PrincipalFromContext(ctx); s.Policy == nil || !ok || !actor.HasRole(RoleAdmin) {
+		newComment.Author = existing.Author
+	}
+	if newComment.Slug == existing.Slug && newComment.Body == existing.Body && newComment.Author == existing.Author {
+		return existing, nil
+	}
+
+	// A thread lives on one article, so only a comment that is neither a
+	// reply nor replied to may move to another.
+	if newComment.Slug != existing.Slug {
+		if existing.ParentID != nil {
+			return Comment{}, ErrSlugMismatch
+		}
+		thread, err := s.Store.GetThreadComments(ctx, ID)
+		if err != nil {
+			return Comment{}, err
+		}
+		if len(thread) > 1 {
+			return Comment{}, ErrSlugMismatch
+		}
+	}
+
+	// Edits go through the same filters as new comments, so content cannot
+	// be slipped in after posting something clean.
+	merged := existing
+	merged.Slug, merged.Body, merged.Author = newComment.Slug, newComment.Body, newComment.Author
+	decisions := s.runFilters(ctx, merged)
+	if err := applyDecisions(&merged, decisions); err != nil {
+		s.recordDecisions(ctx, &ID, decisions)
+		slog.WarnContext(ctx, "comment edit rejected", "id", ID, "err", err)
+		return Comment{}, err
+	}
+
+	edit := Edit{Editor: actorID(ctx), Reason: reason, At: time.Now()}
+	if merged.Status != existing.Status {
+		edit.Moderation = &Moderation{
+			Status: merged.Status,
+			Reason: merged.ModerationReason,
+			At:     edit.At,
+		}
+	} else {
+		edit.Moderation = s.remoderation(ctx, existing, edit.At)
 	}
-	return comment, nil
+	updated, err := s.Store.UpdateComment(ctx, ID, newComment, edit, auditEntry(ctx, AuditUpdate, reason))
+	if err != nil {
+		return Comment{}, err
+	}
+	s.recordDecisions(ctx, &ID, decisions)
+
+	slog.InfoContext(ctx, "comment updated", "id", ID)
+	return updated, nil
 }
 
-func (s *Service) DeleteComment(ID uint) error {
-	if result := s.DB.Delete(&Comment{}, ID); result.Error != nil {
-		return result.Error
+func (s *Service) DeleteComment(ctx context.Context, ID uint) error {
+	existing, err := s.GetComment(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authorize(ctx, ActionDelete, existing); err != nil {
+		return err
+	}
+
+	if err := s.Store.DeleteComment(ctx, ID, auditEntry(ctx, AuditDelete, "")); err != nil {
+		return err
 	}
+
+	slog.InfoContext(ctx, "comment deleted", "id", ID)
 	return nil
 }
 
-func (s *Service) GetAllComments() ([]Comment, error) {
-	var comments []Comment
-
-	if result := s.DB.Find(&comments); result.Error != nil {
-		return comments, result.Error
+func (s *Service) GetAllComments(ctx context.Context) ([]Comment, error) {
+	comments, err := s.Store.GetAllComments(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return comments, nil
+	return s.visible(ctx, comments), nil
 }
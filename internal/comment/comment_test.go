@@ -0,0 +1,722 @@
+package comment_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/database/memory"
+	"github.com/dvl-mukesh/go-workshop/internal/diff"
+	"github.com/dvl-mukesh/go-workshop/internal/request"
+)
+
+var ctx = context.Background()
+
+func newService(opts ...comment.Option) *comment.Service {
+	return comment.NewService(memory.NewCommentStore(), opts...)
+}
+
+func mustPost(t *testing.T, s *comment.Service, c comment.Comment) comment.Comment {
+	t.Helper()
+
+	posted, err := s.PostComment(ctx, c)
+	if err != nil {
+		t.Fatalf("PostComment: %v", err)
+	}
+	return posted
+}
+
+func TestReplyThreading(t *testing.T) {
+	s := newService(comment.WithMaxDepth(2))
+
+	root := mustPost(t, s, comment.Comment{Slug: "post", Body: "root"})
+	child, err := s.ReplyToComment(ctx, root.ID, comment.Comment{Body: "child"})
+	if err != nil {
+		t.Fatalf("ReplyToComment: %v", err)
+	}
+	grandchild, err := s.ReplyToComment(ctx, child.ID, comment.Comment{Body: "grandchild"})
+	if err != nil {
+		t.Fatalf("ReplyToComment: %v", err)
+	}
+
+	if *grandchild.RootID != root.ID || grandchild.Depth != 2 {
+		t.Errorf("grandchild root/depth = %d/%d, want %d/2", *grandchild.RootID, grandchild.Depth, root.ID)
+	}
+
+	if _, err := s.ReplyToComment(ctx, grandchild.ID, comment.Comment{Body: "too deep"}); !errors.Is(err, comment.ErrMaxDepthExceeded) {
+		t.Errorf("reply past max depth: got %v, want ErrMaxDepthExceeded", err)
+	}
+	if _, err := s.ReplyToComment(ctx, 999, comment.Comment{Body: "orphan"}); !errors.Is(err, comment.ErrParentNotFound) {
+		t.Errorf("reply to missing parent: got %v, want ErrParentNotFound", err)
+	}
+}
+
+func TestReplySlug(t *testing.T) {
+	s := newService()
+
+	root := mustPost(t, s, comment.Comment{Slug: "post", Body: "root"})
+	reply, err := s.ReplyToComment(ctx, root.ID, comment.Comment{Body: "reply"})
+	if err != nil {
+		t.Fatalf("ReplyToComment: %v", err)
+	}
+	if reply.Slug != "post" {
+		t.Errorf("reply slug = %q, want the parent's %q", reply.Slug, "post")
+	}
+	if _, err := s.ReplyToComment(ctx, reply.ID, comment.Comment{Slug: "post", Body: "same article"}); err != nil {
+		t.Errorf("reply naming the parent's slug: %v", err)
+	}
+
+	_, err = s.PostComment(ctx, comment.Comment{Slug: "other", Body: "elsewhere", ParentID: &root.ID})
+	if !errors.Is(err, comment.ErrSlugMismatch) {
+		t.Errorf("reply on another article: got %v, want ErrSlugMismatch", err)
+	}
+	other, err := s.GetArticleComments(ctx, "other", comment.ListOptions{})
+	if err != nil {
+		t.Fatalf("GetArticleComments: %v", err)
+	}
+	if len(other.Items) != 0 || other.Count != 0 {
+		t.Errorf("other article has %d comments (count %d), want none", len(other.Items), other.Count)
+	}
+
+	// Moving either end of a thread would split it across articles.
+	for _, c := range []comment.Comment{root, reply} {
+		if _, err := s.UpdateComment(ctx, c.ID, comment.Comment{Slug: "other", Body: c.Body}, ""); !errors.Is(err, comment.ErrSlugMismatch) {
+			t.Errorf("moving comment %d: got %v, want ErrSlugMismatch", c.ID, err)
+		}
+	}
+	lone := mustPost(t, s, comment.Comment{Slug: "post", Body: "lone"})
+	if moved, err := s.UpdateComment(ctx, lone.ID, comment.Comment{Slug: "other", Body: "lone"}, ""); err != nil || moved.Slug != "other" {
+		t.Errorf("moving a comment without replies = %+v, %v", moved, err)
+	}
+}
+
+func TestThreadKeepsTombstones(t *testing.T) {
+	s := newService()
+
+	root := mustPost(t, s, comment.Comment{Body: "root"})
+	child, _ := s.ReplyToComment(ctx, root.ID, comment.Comment{Body: "child", Author: "alice"})
+	leaf, _ := s.ReplyToComment(ctx, root.ID, comment.Comment{Body: "leaf"})
+	s.ReplyToComment(ctx, child.ID, comment.Comment{Body: "grandchild"})
+
+	if err := s.DeleteComment(ctx, child.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	if err := s.DeleteComment(ctx, leaf.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+
+	tree, err := s.GetThread(ctx, root.ID, 0)
+	if err != nil {
+		t.Fatalf("GetThread: %v", err)
+	}
+	if len(tree.Replies) != 1 {
+		t.Fatalf("got %d replies, want 1 (deleted leaf dropped)", len(tree.Replies))
+	}
+	tombstone := tree.Replies[0]
+	if !tombstone.Deleted || tombstone.Body != "" || tombstone.Author != "" {
+		t.Errorf("deleted parent not tombstoned: %+v", tombstone.Comment)
+	}
+	if len(tombstone.Replies) != 1 || tombstone.Replies[0].Body != "grandchild" {
+		t.Errorf("grandchild lost under tombstone")
+	}
+
+	flat, err := s.GetThreadFlat(ctx, root.ID, 1)
+	if err != nil {
+		t.Fatalf("GetThreadFlat: %v", err)
+	}
+	if len(flat) != 2 || flat[1].Depth != 1 {
+		t.Errorf("flat thread limited to depth 1 = %+v", flat)
+	}
+}
+
+func TestListCommentsPaging(t *testing.T) {
+	s := newService()
+
+	for i := 0; i < 5; i++ {
+		mustPost(t, s, comment.Comment{Slug: "a", Author: "bob"})
+	}
+	mustPost(t, s, comment.Comment{Slug: "b"})
+
+	first, err := s.ListComments(ctx, comment.ListOptions{Slug: "a", Limit: 2})
+	if err != nil {
+		t.Fatalf("ListComments: %v", err)
+	}
+	if len(first.Items) != 2 || first.Page.NextCursor == "" || first.Page.PrevCursor != "" {
+		t.Fatalf("unexpected first page: %+v", first.Page)
+	}
+
+	second, err := s.ListComments(ctx, comment.ListOptions{Slug: "a", Limit: 2, Cursor: first.Page.NextCursor})
+	if err != nil {
+		t.Fatalf("ListComments: %v", err)
+	}
+	if second.Items[0].ID != first.Items[1].ID+1 {
+		t.Errorf("second page starts at %d, want %d", second.Items[0].ID, first.Items[1].ID+1)
+	}
+
+	back, err := s.ListComments(ctx, comment.ListOptions{Slug: "a", Limit: 2, Cursor: second.Page.PrevCursor})
+	if err != nil {
+		t.Fatalf("ListComments: %v", err)
+	}
+	if len(back.Items) != 2 || back.Items[0].ID != first.Items[0].ID || back.Page.PrevCursor != "" {
+		t.Errorf("prev page = %+v, want the first page", back)
+	}
+
+	if _, err := s.ListComments(ctx, comment.ListOptions{Slug: "a", Desc: true, Cursor: first.Page.NextCursor}); !errors.Is(err, comment.ErrInvalidCursor) {
+		t.Errorf("cursor reused with different order: got %v, want ErrInvalidCursor", err)
+	}
+	for _, opts := range []comment.ListOptions{
+		{Slug: "b"},
+		{},
+		{Slug: "a", Author: "bob"},
+		{Slug: "a", CreatedAfter: time.Now().Add(-time.Hour)},
+	} {
+		opts.Limit, opts.Cursor = 2, first.Page.NextCursor
+		if _, err := s.ListComments(ctx, opts); !errors.Is(err, comment.ErrInvalidCursor) {
+			t.Errorf("cursor reused with filters %+v: got %v, want ErrInvalidCursor", opts, err)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	now := time.Now()
+	s := newService(comment.WithPolicy(&comment.Policy{
+		EditWindow: time.Hour,
+		Now:        func() time.Time { return now },
+	}))
+
+	alice := auth.WithPrincipal(ctx, auth.Principal{Subject: "alice"})
+	bob := auth.WithPrincipal(ctx, auth.Principal{Subject: "bob"})
+	mod := auth.WithPrincipal(ctx, auth.Principal{Subject: "mo", Roles: []string{comment.RoleModerator}})
+
+	c, err := s.PostComment(alice, comment.Comment{Body: "hi", Author: "mallory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Author != "alice" {
+		t.Errorf("author = %q, want the authenticated principal", c.Author)
+	}
+
+	if _, err := s.UpdateComment(bob, c.ID, comment.Comment{Body: "hijacked"}, ""); !errors.Is(err, comment.ErrForbidden) {
+		t.Errorf("other user update: got %v, want ErrForbidden", err)
+	}
+	if _, err := s.UpdateComment(ctx, c.ID, comment.Comment{Body: "anonymous"}, ""); !errors.Is(err, comment.ErrForbidden) {
+		t.Errorf("anonymous update: got %v, want ErrForbidden", err)
+	}
+
+	updated, err := s.UpdateComment(alice, c.ID, comment.Comment{Body: "edited", Author: "bob"}, "")
+	if err != nil {
+		t.Fatalf("author update: %v", err)
+	}
+	if updated.Author != "alice" {
+		t.Errorf("author reassigned to %q", updated.Author)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := s.UpdateComment(alice, c.ID, comment.Comment{Body: "late"}, ""); !errors.Is(err, comment.ErrEditWindowExpired) {
+		t.Errorf("edit after window: got %v, want ErrEditWindowExpired", err)
+	}
+	if err := s.DeleteComment(mod, c.ID); err != nil {
+		t.Errorf("moderator delete: %v", err)
+	}
+
+	// Without a policy anyone may edit, but not rewrite who wrote what.
+	open := newService()
+	c = mustPost(t, open, comment.Comment{Slug: "a", Body: "hi", Author: "alice"})
+	admin := auth.WithPrincipal(ctx, auth.Principal{Subject: "root", Roles: []string{comment.RoleAdmin}})
+	for _, caller := range []context.Context{ctx, bob, admin} {
+		updated, err := open.UpdateComment(caller, c.ID, comment.Comment{Slug: "a", Body: "edited", Author: "mallory"}, "")
+		if err != nil || updated.Author != "alice" {
+			t.Errorf("update without a policy = %+v, %v, want the author kept", updated, err)
+		}
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind comment.Kind
+		code string
+	}{
+		{comment.ErrCommentNotFound, comment.KindNotFound, "comment_not_found"},
+		{fmt.Errorf("loading parent: %w", comment.ErrParentNotFound), comment.KindNotFound, "parent_not_found"},
+		{comment.ErrMaxDepthExceeded, comment.KindValidation, "max_depth_exceeded"},
+		{comment.ErrEditWindowExpired, comment.KindForbidden, "edit_window_expired"},
+		{fmt.Errorf("%w: %w", comment.ErrConflict, io.EOF), comment.KindConflict, "conflict"},
+		{io.ErrUnexpectedEOF, comment.KindInternal, "internal"},
+	}
+
+	for _, tt := range tests {
+		e := comment.AsError(tt.err)
+		if e.Kind != tt.kind || e.Code != tt.code {
+			t.Errorf("%v: got %s/%s, want %s/%s", tt.err, e.Kind, e.Code, tt.kind, tt.code)
+		}
+	}
+
+	if !errors.Is(comment.ErrEditWindowExpired, comment.ErrForbidden) {
+		t.Error("expected ErrEditWindowExpired to match ErrForbidden")
+	}
+	if msg := comment.AsError(io.ErrUnexpectedEOF).Message; msg == io.ErrUnexpectedEOF.Error() {
+		t.Error("internal error message leaks the cause")
+	}
+}
+
+func TestSearchComments(t *testing.T) {
+	s := newService()
+
+	crash := mustPost(t, s, comment.Comment{Slug: "a", Author: "bob", Body: "The reply button crashes <b>Safari</b>."})
+	mustPost(t, s, comment.Comment{Slug: "a", Author: "amy", Body: "Button to reply is fine, no crash here"})
+	mustPost(t, s, comment.Comment{Slug: "b", Author: "bob", Body: "Unrelated"})
+
+	tests := []struct {
+		query string
+		slug  string
+		want  int
+	}{
+		{`reply button`, "", 2},
+		{`"reply button"`, "", 1},
+		{`crash*`, "", 2},
+		{`crash`, "", 1},
+		{`REPLY`, "b", 0},
+		{`missing`, "", 0},
+	}
+	for _, tt := range tests {
+		page, err := s.SearchComments(ctx, comment.SearchOptions{Query: tt.query, Slug: tt.slug})
+		if err != nil {
+			t.Fatalf("SearchComments(%q): %v", tt.query, err)
+		}
+		if len(page.Items) != tt.want {
+			t.Errorf("SearchComments(%q, slug %q) = %d results, want %d", tt.query, tt.slug, len(page.Items), tt.want)
+		}
+	}
+
+	page, _ := s.SearchComments(ctx, comment.SearchOptions{Query: `"reply button" safari`})
+	if len(page.Items) != 1 || page.Items[0].ID != crash.ID {
+		t.Fatalf("phrase search = %+v, want comment %d", page.Items, crash.ID)
+	}
+	want := "The <mark>reply</mark> <mark>button</mark> crashes &lt;b&gt;<mark>Safari</mark>&lt;/b&gt;."
+	if got := page.Items[0].Snippet; got != want {
+		t.Errorf("snippet = %q, want %q", got, want)
+	}
+
+	first, _ := s.SearchComments(ctx, comment.SearchOptions{Query: "button", Limit: 1})
+	if len(first.Items) != 1 || first.Page.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", first.Page)
+	}
+	second, err := s.SearchComments(ctx, comment.SearchOptions{Query: "button", Limit: 1, Cursor: first.Page.NextCursor})
+	if err != nil || len(second.Items) != 1 || second.Items[0].ID == first.Items[0].ID || second.Page.NextCursor != "" {
+		t.Errorf("second page = %+v, %v", second, err)
+	}
+	if _, err := s.SearchComments(ctx, comment.SearchOptions{Query: "reply", Cursor: first.Page.NextCursor}); !errors.Is(err, comment.ErrInvalidCursor) {
+		t.Errorf("cursor reused with different query: got %v, want ErrInvalidCursor", err)
+	}
+
+	for _, q := range []string{"", ` "" * `, `"unclosed`} {
+		if _, err := s.SearchComments(ctx, comment.SearchOptions{Query: q}); comment.KindOf(err) != comment.KindValidation {
+			t.Errorf("SearchComments(%q): got %v, want a validation error", q, err)
+		}
+	}
+}
+
+func TestModeration(t *testing.T) {
+	s := newService(
+		comment.WithPolicy(&comment.Policy{}),
+		comment.WithDefaultStatus(comment.StatusPending),
+		comment.WithReportThreshold(2),
+	)
+
+	alice := auth.WithPrincipal(ctx, auth.Principal{Subject: "alice"})
+	bob := auth.WithPrincipal(ctx, auth.Principal{Subject: "bob"})
+	carol := auth.WithPrincipal(ctx, auth.Principal{Subject: "carol"})
+	mod := auth.WithPrincipal(ctx, auth.Principal{Subject: "mo", Roles: []string{comment.RoleModerator}})
+
+	mustPost(t, s, comment.Comment{Slug: "a", Body: "anonymous"})
+	c, err := s.PostComment(alice, comment.Comment{Slug: "a", Body: "hello"})
+	if err != nil {
+		t.Fatalf("PostComment: %v", err)
+	}
+	if c.Status != comment.StatusPending {
+		t.Fatalf("new comment status = %q, want pending", c.Status)
+	}
+
+	if _, err := s.GetComment(bob, c.ID); !errors.Is(err, comment.ErrCommentNotFound) {
+		t.Errorf("pending comment shown to others: %v", err)
+	}
+	if _, err := s.GetComment(alice, c.ID); err != nil {
+		t.Errorf("pending comment hidden from its author: %v", err)
+	}
+	if page, _ := s.ListComments(mod, comment.ListOptions{}); len(page.Items) != 0 {
+		t.Errorf("public listing shows %d unapproved comments", len(page.Items))
+	}
+
+	if _, err := s.ModerateComment(alice, c.ID, comment.StatusApproved, ""); !errors.Is(err, comment.ErrForbidden) {
+		t.Errorf("author approving: got %v, want ErrForbidden", err)
+	}
+	if _, err := s.ModerationQueue(bob, comment.ListOptions{}); !errors.Is(err, comment.ErrForbidden) {
+		t.Errorf("queue for non-moderator: got %v, want ErrForbidden", err)
+	}
+	queue, err := s.ModerationQueue(mod, comment.ListOptions{})
+	if err != nil || len(queue.Items) != 2 {
+		t.Fatalf("queue = %+v, %v, want both pending comments", queue.Items, err)
+	}
+
+	if _, err := s.ModerateComment(mod, c.ID, comment.StatusRejected, " "); !errors.Is(err, comment.ErrReasonRequired) {
+		t.Errorf("reject without reason: got %v, want ErrReasonRequired", err)
+	}
+	approved, err := s.ModerateComment(mod, c.ID, comment.StatusApproved, "")
+	if err != nil || approved.Status != comment.StatusApproved || approved.ModeratedBy != "mo" {
+		t.Fatalf("approve = %+v, %v", approved, err)
+	}
+	if n, _ := s.CountCommentsBySlug(ctx, "a"); n != 1 {
+		t.Errorf("count = %d, want the approved comment only", n)
+	}
+
+	if _, err := s.ReportComment(bob, c.ID, "spam"); err != nil {
+		t.Fatalf("ReportComment: %v", err)
+	}
+	if _, err := s.ReportComment(bob, c.ID, "spam"); !errors.Is(err, comment.ErrAlreadyReported) {
+		t.Errorf("second report: got %v, want ErrAlreadyReported", err)
+	}
+	if got, _ := s.GetComment(ctx, c.ID); got.Status != comment.StatusApproved {
+		t.Errorf("flagged below the report threshold")
+	}
+	if _, err := s.ReportComment(carol, c.ID, "rude"); err != nil {
+		t.Fatalf("ReportComment: %v", err)
+	}
+	if _, err := s.GetComment(bob, c.ID); !errors.Is(err, comment.ErrCommentNotFound) {
+		t.Errorf("flagged comment still public: %v", err)
+	}
+	if reports, _ := s.GetReports(mod, c.ID); len(reports) != 2 {
+		t.Errorf("got %d reports, want 2", len(reports))
+	}
+
+	// An approved reply keeps its hidden parent in the thread as a tombstone.
+	reply, _ := s.ReplyToComment(mod, c.ID, comment.Comment{Body: "reply"})
+	s.ModerateComment(mod, reply.ID, comment.StatusApproved, "")
+	tree, err := s.GetThread(bob, c.ID, 0)
+	if err != nil {
+		t.Fatalf("GetThread: %v", err)
+	}
+	if !tree.Hidden || tree.Deleted || tree.Body != "" || len(tree.Replies) != 1 {
+		t.Errorf("hidden parent not tombstoned: %+v", tree)
+	}
+}
+
+func TestVotesAndReactions(t *testing.T) {
+	s := newService()
+
+	users := make([]context.Context, 5)
+	for i := range users {
+		users[i] = auth.WithPrincipal(ctx, auth.Principal{Subject: fmt.Sprintf("user%d", i)})
+	}
+
+	few := mustPost(t, s, comment.Comment{Body: "two upvotes"})
+	many := mustPost(t, s, comment.Comment{Body: "four upvotes, one down"})
+	mustPost(t, s, comment.Comment{Body: "no votes"})
+
+	for _, u := range users[:2] {
+		s.Vote(u, few.ID, 1)
+	}
+	for _, u := range users[:4] {
+		s.Vote(u, many.ID, 1)
+	}
+	s.Vote(users[4], many.ID, 1)
+	voted, err := s.Vote(users[4], many.ID, -1)
+	if err != nil {
+		t.Fatalf("Vote: %v", err)
+	}
+	if voted.Upvotes != 4 || voted.Downvotes != 1 {
+		t.Errorf("votes = +%d/-%d, want a changed vote to replace the old one", voted.Upvotes, voted.Downvotes)
+	}
+	if _, err := s.Vote(users[0], few.ID, 2); !errors.Is(err, comment.ErrInvalidVote) {
+		t.Errorf("vote of 2: got %v, want ErrInvalidVote", err)
+	}
+
+	page, err := s.ListComments(ctx, comment.ListOptions{SortBy: comment.SortScore, Desc: true, Limit: 1})
+	if err != nil {
+		t.Fatalf("ListComments: %v", err)
+	}
+	next, err := s.ListComments(ctx, comment.ListOptions{SortBy: comment.SortScore, Desc: true, Limit: 2, Cursor: page.Page.NextCursor})
+	if err != nil {
+		t.Fatalf("ListComments: %v", err)
+	}
+	if page.Items[0].ID != many.ID || len(next.Items) != 2 || next.Items[0].ID != few.ID {
+		t.Errorf("score order = %d, %+v; want %d then %d", page.Items[0].ID, next.Items, many.ID, few.ID)
+	}
+
+	withdrawn, _ := s.Vote(users[0], few.ID, 0)
+	if withdrawn.Upvotes != 1 || withdrawn.Score >= voted.Score {
+		t.Errorf("after withdrawing: %d upvotes, score %v", withdrawn.Upvotes, withdrawn.Score)
+	}
+
+	s.React(users[0], few.ID, "heart", true)
+	s.React(users[0], few.ID, "heart", true)
+	s.React(users[1], few.ID, "heart", true)
+	reacted, err := s.React(users[0], few.ID, "eyes", true)
+	if err != nil {
+		t.Fatalf("React: %v", err)
+	}
+	if reacted.Reactions["heart"] != 2 || reacted.Reactions["eyes"] != 1 {
+		t.Errorf("reactions = %v, want heart:2 eyes:1", reacted.Reactions)
+	}
+	removed, _ := s.React(users[0], few.ID, "eyes", false)
+	if _, ok := removed.Reactions["eyes"]; ok {
+		t.Errorf("reactions after removal = %v", removed.Reactions)
+	}
+	if _, err := s.React(users[0], few.ID, "poop", true); !errors.Is(err, comment.ErrInvalidReaction) {
+		t.Errorf("unknown reaction: got %v, want ErrInvalidReaction", err)
+	}
+
+	if got, _ := s.GetComment(ctx, few.ID); got.Reactions["heart"] != 2 || got.Upvotes != 1 {
+		t.Errorf("totals not stored on the comment: %+v", got)
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	s := newService(comment.WithPolicy(&comment.Policy{}))
+
+	alice := auth.WithPrincipal(ctx, auth.Principal{Subject: "alice"})
+	bob := auth.WithPrincipal(ctx, auth.Principal{Subject: "bob"})
+
+	c, err := s.PostComment(alice, comment.Comment{Slug: "post", Body: "the quick brown fox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revisions, _ := s.GetRevisions(alice, c.ID); len(revisions) != 0 {
+		t.Errorf("unedited comment has %d revisions", len(revisions))
+	}
+
+	same, err := s.UpdateComment(alice, c.ID, comment.Comment{Slug: "post", Body: "the quick brown fox"}, "")
+	if err != nil || same.Edited {
+		t.Fatalf("no-op update: edited=%v err=%v, want unchanged", same.Edited, err)
+	}
+
+	if _, err := s.UpdateComment(alice, c.ID, comment.Comment{Slug: "post", Body: "the slow brown fox"}, "typo"); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := s.UpdateComment(alice, c.ID, comment.Comment{Slug: "post", Body: "the slow brown dog"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.Edited || updated.EditCount != 2 || updated.EditedAt == nil {
+		t.Errorf("edited/count/at = %v/%d/%v, want true/2/set", updated.Edited, updated.EditCount, updated.EditedAt)
+	}
+
+	revisions, err := s.GetRevisions(alice, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 3 {
+		t.Fatalf("got %d revisions, want 3", len(revisions))
+	}
+	for i, want := range []string{"the quick brown fox", "the slow brown fox", "the slow brown dog"} {
+		if r := revisions[i]; r.Number != i+1 || r.Body != want {
+			t.Errorf("revision %d = %d %q, want %q", i+1, r.Number, r.Body, want)
+		}
+	}
+	if r := revisions[1]; r.Editor != "alice" || r.Reason != "typo" {
+		t.Errorf("revision 2 editor/reason = %q/%q, want alice/typo", r.Editor, r.Reason)
+	}
+
+	if _, err := s.GetRevisions(bob, c.ID); !errors.Is(err, comment.ErrForbidden) {
+		t.Errorf("other user history: got %v, want ErrForbidden", err)
+	}
+
+	d, err := s.DiffRevisions(alice, c.ID, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.From.Number != 1 || d.To.Number != 3 {
+		t.Errorf("diff compares %d..%d, want 1..3", d.From.Number, d.To.Number)
+	}
+	var deleted, inserted string
+	for _, e := range d.Body {
+		switch e.Op {
+		case diff.OpDelete:
+			deleted += e.Text
+		case diff.OpInsert:
+			inserted += e.Text
+		}
+	}
+	if deleted != "quickfox" || inserted != "slowdog" {
+		t.Errorf("deleted %q, inserted %q", deleted, inserted)
+	}
+
+	if _, err := s.DiffRevisions(alice, c.ID, 0, 4); !errors.Is(err, comment.ErrRevisionNotFound) {
+		t.Errorf("diff to missing revision: got %v, want ErrRevisionNotFound", err)
+	}
+}
+
+func TestDeletedComments(t *testing.T) {
+	store := memory.NewCommentStore()
+	s := comment.NewService(store, comment.WithPolicy(&comment.Policy{}))
+
+	alice := auth.WithPrincipal(ctx, auth.Principal{Subject: "alice"})
+	admin := auth.WithPrincipal(ctx, auth.Principal{Subject: "root", Roles: []string{comment.RoleAdmin}})
+
+	root, err := s.PostComment(alice, comment.Comment{Slug: "post", Body: "root"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := s.ReplyToComment(alice, root.ID, comment.Comment{Body: "reply"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateComment(alice, reply.ID, comment.Comment{Slug: "post", Body: "edited reply"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.PurgeComment(admin, root.ID); !errors.Is(err, comment.ErrNotDeleted) {
+		t.Errorf("purge live comment: got %v, want ErrNotDeleted", err)
+	}
+	for _, c := range []comment.Comment{root, reply} {
+		if err := s.DeleteComment(alice, c.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := s.ListDeletedComments(alice, comment.ListOptions{}); !errors.Is(err, comment.ErrForbidden) {
+		t.Errorf("non-admin listing: got %v, want ErrForbidden", err)
+	}
+	// Without a policy nobody counts as an admin.
+	open := comment.NewService(store)
+	if _, err := open.ListDeletedComments(ctx, comment.ListOptions{}); !errors.Is(err, comment.ErrForbidden) {
+		t.Errorf("listing without a policy: got %v, want ErrForbidden", err)
+	}
+	if _, err := open.RestoreComment(admin, root.ID); !errors.Is(err, comment.ErrForbidden) {
+		t.Errorf("restoring without a policy: got %v, want ErrForbidden", err)
+	}
+	if _, err := open.GetAuditLog(ctx, comment.AuditOptions{}); !errors.Is(err, comment.ErrForbidden) {
+		t.Errorf("reading the audit log without a policy: got %v, want ErrForbidden", err)
+	}
+	page, err := s.ListDeletedComments(admin, comment.ListOptions{SortBy: comment.SortDeletedAt, Desc: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Items[0].ID != reply.ID {
+		t.Errorf("deleted listing = %v, want reply then root", page.Items)
+	}
+	if _, err := s.ListComments(ctx, comment.ListOptions{SortBy: comment.SortDeletedAt}); !errors.Is(err, comment.ErrInvalidSort) {
+		t.Errorf("public sort by deleted_at: got %v, want ErrInvalidSort", err)
+	}
+
+	if err := s.PurgeComment(admin, root.ID); !errors.Is(err, comment.ErrHasReplies) {
+		t.Errorf("purge with replies: got %v, want ErrHasReplies", err)
+	}
+	restored, err := s.RestoreComment(admin, root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DeletedAt.Valid {
+		t.Error("restored comment still deleted")
+	}
+	if _, err := s.GetComment(ctx, root.ID); err != nil {
+		t.Errorf("GetComment after restore: %v", err)
+	}
+	if err := s.DeleteComment(alice, root.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	retention := &comment.Retention{Store: store, MaxAge: time.Hour, Now: time.Now}
+	if n, err := retention.Purge(ctx); err != nil || n != 0 {
+		t.Errorf("purge before max age: purged %d, err %v", n, err)
+	}
+	retention.Now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if n, err := retention.Purge(ctx); err != nil || n != 2 {
+		t.Errorf("purge after max age: purged %d, err %v, want 2", n, err)
+	}
+	if _, err := s.RestoreComment(admin, reply.ID); !errors.Is(err, comment.ErrCommentNotFound) {
+		t.Errorf("restore purged comment: got %v, want ErrCommentNotFound", err)
+	}
+	if revisions, _ := store.ListRevisions(ctx, reply.ID); len(revisions) != 0 {
+		t.Errorf("purged comment kept %d revisions", len(revisions))
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	store := memory.NewCommentStore()
+	s := comment.NewService(store, comment.WithPolicy(&comment.Policy{}))
+
+	alice := auth.WithPrincipal(ctx, auth.Principal{Subject: "alice"})
+	alice = request.WithClientIP(request.WithID(alice, "req-1"), "192.0.2.1")
+	mod := auth.WithPrincipal(ctx, auth.Principal{Subject: "mo", Roles: []string{comment.RoleModerator}})
+	admin := auth.WithPrincipal(ctx, auth.Principal{Subject: "root", Roles: []string{comment.RoleAdmin}})
+
+	c, err := s.PostComment(alice, comment.Comment{Slug: "post", Body: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateComment(alice, c.ID, comment.Comment{Slug: "post", Body: "second"}, "typo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ModerateComment(mod, c.ID, comment.StatusRejected, "spam"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteComment(mod, c.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.GetAuditLog(mod, comment.AuditOptions{}); !errors.Is(err, comment.ErrForbidden) {
+		t.Errorf("moderator reading audit log: got %v, want ErrForbidden", err)
+	}
+	if _, err := s.GetAuditLog(admin, comment.AuditOptions{Action: "vote"}); !errors.Is(err, comment.ErrInvalidAuditAction) {
+		t.Errorf("unknown action filter: got %v, want ErrInvalidAuditAction", err)
+	}
+
+	page, err := s.GetAuditLog(admin, comment.AuditOptions{CommentID: c.ID, Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []comment.AuditAction
+	for _, e := range page.Items {
+		actions = append(actions, e.Action)
+	}
+	if fmt.Sprint(actions) != "[delete moderate update]" || page.Page.NextCursor == "" {
+		t.Fatalf("first page = %v (next %q), want newest three", actions, page.Page.NextCursor)
+	}
+	if e := page.Items[0]; e.Actor != "mo" || e.Before == nil || e.After != nil {
+		t.Errorf("delete entry = %+v, want actor mo with a before snapshot only", e)
+	}
+	if e := page.Items[2]; e.Reason != "typo" || e.RequestID != "req-1" || e.ClientIP != "192.0.2.1" || e.Before.EditCount != 0 || e.After.EditCount != 1 {
+		t.Errorf("update entry = %+v", e)
+	}
+
+	page, err = s.GetAuditLog(admin, comment.AuditOptions{CommentID: c.ID, Cursor: page.Page.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Action != comment.AuditCreate || page.Page.NextCursor != "" {
+		t.Errorf("second page = %+v, want just the create", page)
+	}
+
+	retention := &comment.Retention{Store: store, Now: time.Now}
+	if n, err := retention.Purge(ctx); err != nil || n != 1 {
+		t.Fatalf("retention purged %d, err %v", n, err)
+	}
+
+	var buf strings.Builder
+	if err := s.ExportAuditLog(admin, comment.AuditOptions{}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("export has %d lines, want 5", len(lines))
+	}
+	var last comment.AuditEntry
+	if err := json.Unmarshal([]byte(lines[4]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if last.Action != comment.AuditPurge || last.Actor != comment.RetentionActor || last.Before != nil {
+		t.Errorf("last exported entry = %+v, want a retention purge without snapshots", last)
+	}
+	// Snapshots leave out bodies, so nothing of a purged comment remains.
+	if strings.Contains(buf.String(), "first") || strings.Contains(buf.String(), "second") {
+		t.Errorf("audit log still holds the purged comment's body:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,91 @@
+package comment
+
+import "errors"
+
+// Kind classifies a domain error so the transport can pick a status code
+// without knowing every individual error.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindForbidden
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation"
+	case KindForbidden:
+		return "forbidden"
+	default:
+		return "internal"
+	}
+}
+
+// Error is a domain error. Code is a stable, machine readable identifier
+// clients can switch on and Message is safe to show them. Err is the
+// underlying cause, if any, and is only meant for logs.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func Validation(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+// Internal wraps an unexpected failure, such as a database error. Its
+// message never includes the cause.
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Code: "internal", Message: "internal error", Err: err}
+}
+
+// ErrConflict is returned when a write collides with existing data.
+var ErrConflict = Conflict("conflict", "conflicts with existing data")
+
+// AsError returns the domain error in err's chain. Errors that aren't
+// domain errors are reported as internal.
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal(err)
+}
+
+// KindOf returns the kind of err, or KindInternal for non-domain errors.
+func KindOf(err error) Kind {
+	return AsError(err).Kind
+}
@@ -0,0 +1,21 @@
+package comment
+
+import (
+	"strconv"
+
+	"github.com/dvl-mukesh/go-workshop/internal/outbox"
+)
+
+// Domain events the stores add to the outbox in the same transaction as the
+// change. Each carries the comment as it was left by the change.
+const (
+	EventCommentCreated = "comment.created"
+	// EventCommentUpdated covers edits, moderation and restores.
+	EventCommentUpdated = "comment.updated"
+	EventCommentDeleted = "comment.deleted"
+)
+
+// NewEvent builds the outbox event announcing a change to c.
+func NewEvent(eventType string, c Comment) (outbox.Event, error) {
+	return outbox.New(eventType, strconv.FormatUint(uint64(c.ID), 10), c)
+}
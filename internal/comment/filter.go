@@ -0,0 +1,157 @@
+package comment
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Verdict is what a content filter decides about a new or edited comment.
+type Verdict string
+
+const (
+	VerdictAllow Verdict = "allow"
+	// VerdictAnnotate lets the comment through but records labels for
+	// moderators.
+	VerdictAnnotate Verdict = "annotate"
+	// VerdictHold holds the comment for moderation.
+	VerdictHold   Verdict = "hold"
+	VerdictReject Verdict = "reject"
+)
+
+// ErrContentRejected is matched with errors.Is by the errors returned for
+// comments a filter rejects.
+var ErrContentRejected = Validation("content_rejected", "comment rejected by content filter")
+
+// Decision is a filter's verdict on a comment. Decisions other than allow
+// are recorded for audit, along with the comment they were about, if it was
+// stored.
+type Decision struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID *uint     `json:"comment_id" gorm:"index"`
+	Author    string    `json:"author"`
+	Filter    string    `json:"filter"`
+	Verdict   Verdict   `json:"verdict"`
+	Reason    string    `json:"reason,omitempty"`
+	Labels    []string  `json:"labels,omitempty" gorm:"serializer:json"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Decision) TableName() string {
+	return "filter_decisions"
+}
+
+// Filter inspects a comment before it is stored, both when it is posted
+// and when it is edited. Edited comments keep their ID, which is zero for
+// new ones. Filters only fill in Verdict, Reason and Labels; the rest of
+// the decision is added by the service.
+type Filter interface {
+	Name() string
+	Check(ctx context.Context, c Comment) (Decision, error)
+}
+
+type filterFunc struct {
+	name string
+	fn   func(ctx context.Context, c Comment) (Decision, error)
+}
+
+func (f filterFunc) Name() string { return f.name }
+
+func (f filterFunc) Check(ctx context.Context, c Comment) (Decision, error) {
+	return f.fn(ctx, c)
+}
+
+// FilterFunc adapts a function, such as a call to an external classifier,
+// to a Filter.
+func FilterFunc(name string, fn func(ctx context.Context, c Comment) (Decision, error)) Filter {
+	return filterFunc{name: name, fn: fn}
+}
+
+// WithFilters runs new and edited comments through filters, in order,
+// before they are stored.
+func WithFilters(filters ...Filter) Option {
+	return func(s *Service) {
+		s.Filters = append(s.Filters, filters...)
+	}
+}
+
+// runFilters applies the filters to c, stopping at the first rejection. A
+// filter that fails holds the comment rather than letting it through
+// unchecked.
+func (s *Service) runFilters(ctx context.Context, c Comment) []Decision {
+	var decisions []Decision
+	for _, f := range s.Filters {
+		d, err := f.Check(ctx, c)
+		if err != nil {
+			slog.ErrorContext(ctx, "content filter failed", "filter", f.Name(), "err", err)
+			d = Decision{Verdict: VerdictHold, Reason: "filter failed"}
+		}
+		if d.Verdict == "" || d.Verdict == VerdictAllow {
+			continue
+		}
+
+		d.Filter = f.Name()
+		d.Author = c.Author
+		decisions = append(decisions, d)
+		if d.Verdict == VerdictReject {
+			break
+		}
+	}
+	return decisions
+}
+
+// applyDecisions holds c for moderation if any filter asked for it and
+// returns an error if one rejected it.
+func applyDecisions(c *Comment, decisions []Decision) error {
+	var held []string
+	for _, d := range decisions {
+		switch d.Verdict {
+		case VerdictReject:
+			return &Error{
+				Kind:    KindValidation,
+				Code:    ErrContentRejected.Code,
+				Message: ErrContentRejected.Message + ": " + d.Reason,
+				Err:     ErrContentRejected,
+			}
+		case VerdictHold:
+			held = append(held, d.Filter+": "+d.Reason)
+		}
+	}
+
+	if len(held) > 0 && c.Status == StatusApproved {
+		c.Status = StatusPending
+		c.ModerationReason = "held by " + strings.Join(held, "; ")
+	}
+	return nil
+}
+
+// recordDecisions stores the decisions made about a comment. Failures are
+// logged rather than failing the request, which has already succeeded or
+// been rejected by then.
+func (s *Service) recordDecisions(ctx context.Context, commentID *uint, decisions []Decision) {
+	if len(decisions) == 0 {
+		return
+	}
+	now := time.Now()
+	for i := range decisions {
+		decisions[i].CommentID = commentID
+		decisions[i].CreatedAt = now
+	}
+	if err := s.Store.RecordDecisions(ctx, decisions); err != nil {
+		slog.ErrorContext(ctx, "recording content filter decisions", "err", err)
+	}
+}
+
+// GetDecisions returns the content filter decisions made about a comment,
+// for moderators.
+func (s *Service) GetDecisions(ctx context.Context, ID uint) ([]Decision, error) {
+	existing, err := s.Store.GetComment(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, ActionModerate, existing); err != nil {
+		return nil, err
+	}
+	return s.Store.ListDecisions(ctx, ID)
+}
@@ -0,0 +1,228 @@
+package comment
+
+import (
+	"context"
+	"io"
+)
+
+// Hook is called when a service operation starts. It may return a derived
+// context for the operation and returns a function that is called with the
+// operation's error once it finishes.
+type Hook func(ctx context.Context, op string) (context.Context, func(err error))
+
+// Instrumented wraps a CommentService and reports every call to its hooks,
+// for metrics and tracing.
+type Instrumented struct {
+	next  CommentService
+	hooks []Hook
+}
+
+// Instrument returns svc wrapped so that every operation is reported to
+// hooks in order.
+func Instrument(svc CommentService, hooks ...Hook) *Instrumented {
+	return &Instrumented{next: svc, hooks: hooks}
+}
+
+func (i *Instrumented) start(ctx context.Context, op string) (context.Context, func(error)) {
+	dones := make([]func(error), 0, len(i.hooks))
+	for _, h := range i.hooks {
+		var done func(error)
+		ctx, done = h(ctx, op)
+		dones = append(dones, done)
+	}
+
+	return ctx, func(err error) {
+		for j := len(dones) - 1; j >= 0; j-- {
+			dones[j](err)
+		}
+	}
+}
+
+func (i *Instrumented) GetComment(ctx context.Context, ID uint) (Comment, error) {
+	ctx, done := i.start(ctx, "GetComment")
+	c, err := i.next.GetComment(ctx, ID)
+	done(err)
+	return c, err
+}
+
+func (i *Instrumented) GetCommentBySlug(ctx context.Context, slug string) ([]Comment, error) {
+	ctx, done := i.start(ctx, "GetCommentBySlug")
+	cs, err := i.next.GetCommentBySlug(ctx, slug)
+	done(err)
+	return cs, err
+}
+
+func (i *Instrumented) PostComment(ctx context.Context, comment Comment) (Comment, error) {
+	ctx, done := i.start(ctx, "PostComment")
+	c, err := i.next.PostComment(ctx, comment)
+	done(err)
+	return c, err
+}
+
+func (i *Instrumented) ReplyToComment(ctx context.Context, parentID uint, reply Comment) (Comment, error) {
+	ctx, done := i.start(ctx, "ReplyToComment")
+	c, err := i.next.ReplyToComment(ctx, parentID, reply)
+	done(err)
+	return c, err
+}
+
+func (i *Instrumented) UpdateComment(ctx context.Context, ID uint, newComment Comment, reason string) (Comment, error) {
+	ctx, done := i.start(ctx, "UpdateComment")
+	c, err := i.next.UpdateComment(ctx, ID, newComment, reason)
+	done(err)
+	return c, err
+}
+
+func (i *Instrumented) DeleteComment(ctx context.Context, ID uint) error {
+	ctx, done := i.start(ctx, "DeleteComment")
+	err := i.next.DeleteComment(ctx, ID)
+	done(err)
+	return err
+}
+
+func (i *Instrumented) GetAllComments(ctx context.Context) ([]Comment, error) {
+	ctx, done := i.start(ctx, "GetAllComments")
+	cs, err := i.next.GetAllComments(ctx)
+	done(err)
+	return cs, err
+}
+
+func (i *Instrumented) ListComments(ctx context.Context, opts ListOptions) (Page, error) {
+	ctx, done := i.start(ctx, "ListComments")
+	p, err := i.next.ListComments(ctx, opts)
+	done(err)
+	return p, err
+}
+
+func (i *Instrumented) CountCommentsBySlug(ctx context.Context, slug string) (int64, error) {
+	ctx, done := i.start(ctx, "CountCommentsBySlug")
+	n, err := i.next.CountCommentsBySlug(ctx, slug)
+	done(err)
+	return n, err
+}
+
+func (i *Instrumented) GetArticleComments(ctx context.Context, slug string, opts ListOptions) (ArticleComments, error) {
+	ctx, done := i.start(ctx, "GetArticleComments")
+	a, err := i.next.GetArticleComments(ctx, slug, opts)
+	done(err)
+	return a, err
+}
+
+func (i *Instrumented) GetThread(ctx context.Context, ID uint, maxDepth int) (*ThreadNode, error) {
+	ctx, done := i.start(ctx, "GetThread")
+	t, err := i.next.GetThread(ctx, ID, maxDepth)
+	done(err)
+	return t, err
+}
+
+func (i *Instrumented) GetThreadFlat(ctx context.Context, ID uint, maxDepth int) ([]ThreadNode, error) {
+	ctx, done := i.start(ctx, "GetThreadFlat")
+	t, err := i.next.GetThreadFlat(ctx, ID, maxDepth)
+	done(err)
+	return t, err
+}
+
+func (i *Instrumented) SearchComments(ctx context.Context, opts SearchOptions) (SearchPage, error) {
+	ctx, done := i.start(ctx, "SearchComments")
+	p, err := i.next.SearchComments(ctx, opts)
+	done(err)
+	return p, err
+}
+
+func (i *Instrumented) ModerateComment(ctx context.Context, ID uint, status Status, reason string) (Comment, error) {
+	ctx, done := i.start(ctx, "ModerateComment")
+	c, err := i.next.ModerateComment(ctx, ID, status, reason)
+	done(err)
+	return c, err
+}
+
+func (i *Instrumented) ReportComment(ctx context.Context, ID uint, reason string) (Report, error) {
+	ctx, done := i.start(ctx, "ReportComment")
+	r, err := i.next.ReportComment(ctx, ID, reason)
+	done(err)
+	return r, err
+}
+
+func (i *Instrumented) GetReports(ctx context.Context, ID uint) ([]Report, error) {
+	ctx, done := i.start(ctx, "GetReports")
+	rs, err := i.next.GetReports(ctx, ID)
+	done(err)
+	return rs, err
+}
+
+func (i *Instrumented) ModerationQueue(ctx context.Context, opts ListOptions) (Page, error) {
+	ctx, done := i.start(ctx, "ModerationQueue")
+	p, err := i.next.ModerationQueue(ctx, opts)
+	done(err)
+	return p, err
+}
+
+func (i *Instrumented) GetDecisions(ctx context.Context, ID uint) ([]Decision, error) {
+	ctx, done := i.start(ctx, "GetDecisions")
+	ds, err := i.next.GetDecisions(ctx, ID)
+	done(err)
+	return ds, err
+}
+
+func (i *Instrumented) Vote(ctx context.Context, ID uint, value int) (Comment, error) {
+	ctx, done := i.start(ctx, "Vote")
+	c, err := i.next.Vote(ctx, ID, value)
+	done(err)
+	return c, err
+}
+
+func (i *Instrumented) React(ctx context.Context, ID uint, kind string, add bool) (Comment, error) {
+	ctx, done := i.start(ctx, "React")
+	c, err := i.next.React(ctx, ID, kind, add)
+	done(err)
+	return c, err
+}
+
+func (i *Instrumented) GetRevisions(ctx context.Context, ID uint) ([]Revision, error) {
+	ctx, done := i.start(ctx, "GetRevisions")
+	rs, err := i.next.GetRevisions(ctx, ID)
+	done(err)
+	return rs, err
+}
+
+func (i *Instrumented) DiffRevisions(ctx context.Context, ID uint, from, to int) (RevisionDiff, error) {
+	ctx, done := i.start(ctx, "DiffRevisions")
+	d, err := i.next.DiffRevisions(ctx, ID, from, to)
+	done(err)
+	return d, err
+}
+
+func (i *Instrumented) ListDeletedComments(ctx context.Context, opts ListOptions) (Page, error) {
+	ctx, done := i.start(ctx, "ListDeletedComments")
+	p, err := i.next.ListDeletedComments(ctx, opts)
+	done(err)
+	return p, err
+}
+
+func (i *Instrumented) RestoreComment(ctx context.Context, ID uint) (Comment, error) {
+	ctx, done := i.start(ctx, "RestoreComment")
+	c, err := i.next.RestoreComment(ctx, ID)
+	done(err)
+	return c, err
+}
+
+func (i *Instrumented) PurgeComment(ctx context.Context, ID uint) error {
+	ctx, done := i.start(ctx, "PurgeComment")
+	err := i.next.PurgeComment(ctx, ID)
+	done(err)
+	return err
+}
+
+func (i *Instrumented) GetAuditLog(ctx context.Context, opts AuditOptions) (AuditPage, error) {
+	ctx, done := i.start(ctx, "GetAuditLog")
+	p, err := i.next.GetAuditLog(ctx, opts)
+	done(err)
+	return p, err
+}
+
+func (i *Instrumented) ExportAuditLog(ctx context.Context, opts AuditOptions, w io.Writer) error {
+	ctx, done := i.start(ctx, "ExportAuditLog")
+	err := i.next.ExportAuditLog(ctx, opts, w)
+	done(err)
+	return err
+}
@@ -0,0 +1,237 @@
+package comment
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	// SortScore orders by WilsonScore of the votes.
+	SortScore = "score"
+	// SortDeletedAt is only valid when listing deleted comments.
+	SortDeletedAt = "deleted_at"
+)
+
+var (
+	ErrInvalidCursor = Validation("invalid_cursor", "invalid cursor")
+	ErrInvalidSort   = Validation("invalid_sort", "invalid sort field, expected created_at, updated_at or score")
+)
+
+// ListOptions filters, sorts and pages a comment listing. Zero values mean
+// "no filter", created_at ascending and the default page size.
+type ListOptions struct {
+	Limit         int
+	Cursor        string
+	Slug          string
+	Author        string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	SortBy        string
+	Desc          bool
+	// Statuses limits the listing to comments in these moderation states.
+	// Public listings always show approved comments only.
+	Statuses []Status
+	// Deleted lists soft-deleted comments instead of live ones.
+	Deleted bool
+}
+
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+type Page struct {
+	Items []Comment `json:"items"`
+	Page  PageInfo  `json:"page"`
+}
+
+// cursor is the keyset position encoded into the opaque next/prev tokens. The
+// sort settings and filters are part of it so a cursor can't be replayed
+// against a different ordering or listing.
+type cursor struct {
+	Value  time.Time `json:"v"`
+	Score  float64   `json:"sc,omitempty"`
+	ID     uint      `json:"id"`
+	SortBy string    `json:"s"`
+	Desc   bool      `json:"d,omitempty"`
+	Prev   bool      `json:"p,omitempty"`
+	listFilters
+}
+
+// listFilters are the ListOptions filters a cursor was issued for.
+type listFilters struct {
+	Slug          string     `json:"sl,omitempty"`
+	Author        string     `json:"au,omitempty"`
+	CreatedAfter  *time.Time `json:"ca,omitempty"`
+	CreatedBefore *time.Time `json:"cb,omitempty"`
+	Statuses      []Status   `json:"st,omitempty"`
+	Deleted       bool       `json:"del,omitempty"`
+}
+
+func (opts ListOptions) filters() listFilters {
+	return listFilters{
+		Slug:          opts.Slug,
+		Author:        opts.Author,
+		CreatedAfter:  timeOrNil(opts.CreatedAfter),
+		CreatedBefore: timeOrNil(opts.CreatedBefore),
+		Statuses:      opts.Statuses,
+		Deleted:       opts.Deleted,
+	}
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func (f listFilters) equal(g listFilters) bool {
+	sameTime := func(a, b *time.Time) bool {
+		return (a == nil && b == nil) || (a != nil && b != nil && a.Equal(*b))
+	}
+	return f.Slug == g.Slug && f.Author == g.Author && f.Deleted == g.Deleted &&
+		sameTime(f.CreatedAfter, g.CreatedAfter) && sameTime(f.CreatedBefore, g.CreatedBefore) &&
+		slices.Equal(f.Statuses, g.Statuses)
+}
+
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// WithMaxPageSize caps the page size clients may request. Non-positive values
+// keep the default.
+func WithMaxPageSize(size int) Option {
+	return func(s *Service) {
+		if size > 0 {
+			s.MaxPageSize = size
+		}
+	}
+}
+
+func (opts *ListOptions) normalize(maxPageSize int) error {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultPageSize
+	}
+	if opts.Limit > maxPageSize {
+		opts.Limit = maxPageSize
+	}
+
+	switch opts.SortBy {
+	case "":
+		opts.SortBy = SortCreatedAt
+	case SortCreatedAt, SortUpdatedAt, SortScore:
+	case SortDeletedAt:
+		if !opts.Deleted {
+			return ErrInvalidSort
+		}
+	default:
+		return ErrInvalidSort
+	}
+	return nil
+}
+
+// ListComments returns one page of approved comments using keyset
+// pagination on (sort column, id).
+func (s *Service) ListComments(ctx context.Context, opts ListOptions) (Page, error) {
+	opts.Statuses = []Status{StatusApproved}
+	return s.listComments(ctx, opts)
+}
+
+func (s *Service) listComments(ctx context.Context, opts ListOptions) (Page, error) {
+	if err := opts.normalize(s.MaxPageSize); err != nil {
+		return Page{}, err
+	}
+
+	var cur *cursor
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		if c.SortBy != opts.SortBy || c.Desc != opts.Desc || !c.listFilters.equal(opts.filters()) {
+			return Page{}, ErrInvalidCursor
+		}
+		cur = &c
+	}
+
+	backward := cur != nil && cur.Prev
+	query := ListQuery{
+		Slug:          opts.Slug,
+		Author:        opts.Author,
+		CreatedAfter:  opts.CreatedAfter,
+		CreatedBefore: opts.CreatedBefore,
+		Statuses:      opts.Statuses,
+		Deleted:       opts.Deleted,
+		SortBy:        opts.SortBy,
+		// Walking backwards flips the scan direction; the page is reversed
+		// again below so items are always returned in the requested order.
+		Desc:  opts.Desc != backward,
+		Limit: opts.Limit + 1,
+	}
+	if cur != nil {
+		query.After = &Position{Value: cur.Value, Score: cur.Score, ID: cur.ID}
+	}
+
+	comments, err := s.Store.ListComments(ctx, query)
+	if err != nil {
+		return Page{}, err
+	}
+
+	hasMore := len(comments) > opts.Limit
+	if hasMore {
+		comments = comments[:opts.Limit]
+	}
+	if backward {
+		slices.Reverse(comments)
+	}
+
+	page := Page{
+		Items: comments,
+		Page:  PageInfo{Limit: opts.Limit},
+	}
+	if len(comments) == 0 {
+		return page, nil
+	}
+
+	first := comments[0].Position(opts.SortBy)
+	last := comments[len(comments)-1].Position(opts.SortBy)
+	if (backward && hasMore) || (!backward && cur != nil) {
+		page.Page.PrevCursor = cursor{
+			Value: first.Value, Score: first.Score, ID: first.ID,
+			SortBy: opts.SortBy, Desc: opts.Desc, Prev: true,
+			listFilters: opts.filters(),
+		}.encode()
+	}
+	if (!backward && hasMore) || backward {
+		page.Page.NextCursor = cursor{
+			Value: last.Value, Score: last.Score, ID: last.ID,
+			SortBy: opts.SortBy, Desc: opts.Desc,
+			listFilters: opts.filters(),
+		}.encode()
+	}
+
+	return page, nil
+}
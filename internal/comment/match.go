@@ -0,0 +1,164 @@
+package comment
+
+import (
+	"cmp"
+	"html"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Snippets mark matches with these, like Postgres' ts_headline.
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+// snippetWords is how many words a snippet holds, and snippetLead how many
+// of them come before the first match.
+const (
+	snippetWords = 30
+	snippetLead  = 5
+)
+
+type token struct {
+	word       string
+	start, end int
+}
+
+func tokenize(s string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range s {
+		inWord := unicode.IsLetter(r) || unicode.IsNumber(r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			tokens = append(tokens, token{strings.ToLower(s[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(s[start:]), start, len(s)})
+	}
+	return tokens
+}
+
+func (t Term) matchesAt(tokens []token, i int) bool {
+	if i+len(t.Words) > len(tokens) {
+		return false
+	}
+	last := len(t.Words) - 1
+	for j, w := range t.Words {
+		got := tokens[i+j].word
+		if j == last && t.Prefix {
+			if !strings.HasPrefix(got, w) {
+				return false
+			}
+		} else if got != w {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchSearch reports whether c's body contains every term and, if so, ranks
+// it and builds its snippet. It is a simple stand-in for Postgres full-text
+// search, for stores without one: words are compared exactly, without
+// stemming or stop words.
+func MatchSearch(c Comment, terms []Term) (SearchResult, bool) {
+	tokens := tokenize(c.Body)
+	marked := make([]bool, len(tokens))
+
+	hits := 0
+	for _, t := range terms {
+		found := false
+		for i := range tokens {
+			if t.matchesAt(tokens, i) {
+				found = true
+				hits++
+				for j := range t.Words {
+					marked[i+j] = true
+				}
+			}
+		}
+		if !found {
+			return SearchResult{}, false
+		}
+	}
+
+	// Favour more matches, but not simply longer comments.
+	rank := float64(hits) / (1 + math.Log(float64(len(tokens))))
+	return SearchResult{
+		Comment: c,
+		Rank:    rank,
+		Snippet: snippet(c.Body, tokens, marked),
+	}, true
+}
+
+// snippet returns the words around the first match, HTML-escaped and with
+// the marked words highlighted.
+func snippet(body string, tokens []token, marked []bool) string {
+	first := 0
+	for i, m := range marked {
+		if m {
+			first = i
+			break
+		}
+	}
+	from := max(first-snippetLead, 0)
+	to := min(from+snippetWords, len(tokens))
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("… ")
+	}
+	pos := tokens[from].start
+	for i := from; i < to; i++ {
+		t := tokens[i]
+		b.WriteString(html.EscapeString(body[pos:t.start]))
+		if marked[i] {
+			b.WriteString(HighlightStart + html.EscapeString(body[t.start:t.end]) + HighlightStop)
+		} else {
+			b.WriteString(html.EscapeString(body[t.start:t.end]))
+		}
+		pos = t.end
+	}
+	if to < len(tokens) {
+		b.WriteString(" …")
+	} else {
+		// Keep trailing punctuation such as a closing full stop.
+		b.WriteString(html.EscapeString(strings.TrimRightFunc(body[pos:], unicode.IsSpace)))
+	}
+	return b.String()
+}
+
+// RankSearch runs q over comments with MatchSearch, returning the requested
+// page of matches ordered by rank and then newest first. Filters other than
+// the terms are left to the caller.
+func RankSearch(comments []Comment, q SearchQuery) []SearchResult {
+	results := []SearchResult{}
+	for _, c := range comments {
+		if r, ok := MatchSearch(c, q.Terms); ok {
+			results = append(results, r)
+		}
+	}
+
+	slices.SortFunc(results, func(a, b SearchResult) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	if q.Offset >= len(results) {
+		return []SearchResult{}
+	}
+	results = results[q.Offset:]
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results
+}
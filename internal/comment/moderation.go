@@ -0,0 +1,220 @@
+package comment
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+)
+
+// Status is where a comment is in moderation. Only approved comments are
+// shown publicly.
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusRejected Status = "rejected"
+	StatusFlagged  Status = "flagged"
+)
+
+// DefaultReportThreshold is how many reports flag an approved comment for
+// review.
+const DefaultReportThreshold = 3
+
+var (
+	ErrInvalidStatus   = Validation("invalid_status", "invalid status, expected pending, approved, rejected or flagged")
+	ErrReasonRequired  = Validation("reason_required", "a reason is required")
+	ErrAlreadyReported = Conflict("already_reported", "you have already reported this comment")
+)
+
+// ParseStatus checks that s names a moderation status.
+func ParseStatus(s string) (Status, error) {
+	switch status := Status(s); status {
+	case StatusPending, StatusApproved, StatusRejected, StatusFlagged:
+		return status, nil
+	}
+	return "", ErrInvalidStatus
+}
+
+// Moderation is a moderator's decision about a comment.
+type Moderation struct {
+	Status Status
+	Reason string
+	By     string
+	At     time.Time
+}
+
+// Report is a user's complaint about a comment. Each reporter may report a
+// comment once.
+type Report struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" gorm:"uniqueIndex:idx_comment_reports_comment_reporter"`
+	Reporter  string    `json:"reporter" gorm:"uniqueIndex:idx_comment_reports_comment_reporter"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Report) TableName() string {
+	return "comment_reports"
+}
+
+// WithDefaultStatus sets the status new comments start in. Use
+// StatusPending to hold every comment for review.
+func WithDefaultStatus(status Status) Option {
+	return func(s *Service) {
+		if status != "" {
+			s.DefaultStatus = status
+		}
+	}
+}
+
+// WithReportThreshold sets how many reports flag a comment. Non-positive
+// values keep the default.
+func WithReportThreshold(n int) Option {
+	return func(s *Service) {
+		if n > 0 {
+			s.ReportThreshold = n
+		}
+	}
+}
+
+// isModerator reports whether the principal in ctx moderates comments.
+func isModerator(ctx context.Context) bool {
+	actor, ok := auth.PrincipalFromContext(ctx)
+	return ok && (actor.HasRole(RoleModerator) || actor.HasRole(RoleAdmin))
+}
+
+// canSee reports whether the caller may see c: approved comments are public,
+// while the others are only shown to moderators and their author.
+func (s *Service) canSee(ctx context.Context, c Comment) bool {
+	if c.Status == StatusApproved || isModerator(ctx) {
+		return true
+	}
+	actor, ok := auth.PrincipalFromContext(ctx)
+	return ok && c.Author != "" && c.Author == actor.Subject
+}
+
+// ModerateComment sets the status of a comment. Rejecting needs a reason.
+func (s *Service) ModerateComment(ctx context.Context, ID uint, status Status, reason string) (Comment, error) {
+	if _, err := ParseStatus(string(status)); err != nil {
+		return Comment{}, err
+	}
+	reason = strings.TrimSpace(reason)
+	if status == StatusRejected && reason == "" {
+		return Comment{}, ErrReasonRequired
+	}
+
+	existing, err := s.Store.GetComment(ctx, ID)
+	if err != nil {
+		return Comment{}, err
+	}
+	if err := s.authorize(ctx, ActionModerate, existing); err != nil {
+		return Comment{}, err
+	}
+
+	var by string
+	if actor, ok := auth.PrincipalFromContext(ctx); ok {
+		by = actor.Subject
+	}
+
+	moderated, err := s.Store.SetCommentStatus(ctx, ID, Moderation{
+		Status: status,
+		Reason: reason,
+		By:     by,
+		At:     time.Now(),
+	}, auditEntry(ctx, AuditModerate, reason))
+	if err != nil {
+		return Comment{}, err
+	}
+
+	slog.InfoContext(ctx, "comment moderated", "id", ID, "status", status, "from", existing.Status, "by", by)
+	return moderated, nil
+}
+
+// ReportComment records a complaint about a visible comment by the caller,
+// identified by their subject or, for anonymous callers, their IP. Once
+// ReportThreshold reports are in, an approved comment is flagged for review.
+func (s *Service) ReportComment(ctx context.Context, ID uint, reason string) (Report, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return Report{}, ErrReasonRequired
+	}
+
+	existing, err := s.GetComment(ctx, ID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report, reported, err := s.Store.CreateReport(ctx, Report{
+		CommentID: existing.ID,
+		Reporter:  actorID(ctx),
+		Reason:    reason,
+	})
+	if err != nil {
+		return Report{}, err
+	}
+	slog.InfoContext(ctx, "comment reported", "id", ID, "reports", reported.ReportCount)
+
+	if reported.Status == StatusApproved && reported.ReportCount >= s.ReportThreshold {
+		reason := "reported by users"
+		_, err := s.Store.SetCommentStatus(ctx, ID, Moderation{
+			Status: StatusFlagged,
+			Reason: reason,
+			At:     time.Now(),
+		}, auditEntry(ctx, AuditModerate, reason))
+		if err != nil {
+			return Report{}, err
+		}
+		slog.InfoContext(ctx, "comment flagged", "id", ID, "reports", reported.ReportCount)
+	}
+	return report, nil
+}
+
+// GetReports returns the reports filed against a comment, for moderators.
+func (s *Service) GetReports(ctx context.Context, ID uint) ([]Report, error) {
+	existing, err := s.Store.GetComment(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, ActionModerate, existing); err != nil {
+		return nil, err
+	}
+	return s.Store.ListReports(ctx, ID)
+}
+
+// ModerationQueue lists comments awaiting review, pending and flagged ones
+// unless opts.Statuses asks for others, for moderators.
+func (s *Service) ModerationQueue(ctx context.Context, opts ListOptions) (Page, error) {
+	if err := s.authorize(ctx, ActionModerate, Comment{}); err != nil {
+		return Page{}, err
+	}
+	if len(opts.Statuses) == 0 {
+		opts.Statuses = []Status{StatusPending, StatusFlagged}
+	}
+	for _, status := range opts.Statuses {
+		if _, err := ParseStatus(string(status)); err != nil {
+			return Page{}, err
+		}
+	}
+	return s.listComments(ctx, opts)
+}
+
+// remoderation returns the status change sending a comment edited by its
+// author back for review when new comments are held for moderation, or nil.
+func (s *Service) remoderation(ctx context.Context, c Comment, at time.Time) *Moderation {
+	if s.DefaultStatus != StatusPending || c.Status == StatusPending || isModerator(ctx) {
+		return nil
+	}
+	return &Moderation{Status: StatusPending, Reason: "edited", At: at}
+}
+
+// visible drops the comments the caller may not see.
+func (s *Service) visible(ctx context.Context, comments []Comment) []Comment {
+	return slices.DeleteFunc(comments, func(c Comment) bool {
+		return !s.canSee(ctx, c)
+	})
+}
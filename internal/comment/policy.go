@@ -0,0 +1,112 @@
+package comment
+
+import (
+	"context"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+)
+
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+)
+
+// Action is an operation subject to the authorization policy.
+type Action string
+
+const (
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// ActionModerate covers approving, rejecting and reviewing comments.
+	ActionModerate Action = "moderate"
+	// ActionViewHistory covers reading a comment's revisions.
+	ActionViewHistory Action = "view_history"
+	// ActionManageDeleted covers listing, restoring and purging deleted
+	// comments, which only admins may do.
+	ActionManageDeleted Action = "manage_deleted"
+	// ActionViewAudit covers reading the audit log, also admins only.
+	ActionViewAudit Action = "view_audit"
+)
+
+var (
+	ErrForbidden = Forbidden("forbidden", "you are not allowed to modify this comment")
+	// ErrEditWindowExpired matches ErrForbidden with errors.Is.
+	ErrEditWindowExpired = &Error{
+		Kind:    KindForbidden,
+		Code:    "edit_window_expired",
+		Message: "edit window has expired",
+		Err:     ErrForbidden,
+	}
+)
+
+// Policy decides who may act on a comment: admins may do anything,
+// moderators may update, delete or moderate any comment and authors may
+// update or delete their own, within EditWindow when it is set. Moderators
+// and authors may also read a comment's edit history.
+type Policy struct {
+	EditWindow time.Duration
+	Now        func() time.Time
+}
+
+// WithPolicy enforces p on comment mutations. Without a policy, for example
+// when authentication is disabled, every caller may modify every comment,
+// but nobody may reassign one to another author or perform the admin-only
+// actions, since admins cannot be told apart.
+func WithPolicy(p *Policy) Option {
+	return func(s *Service) {
+		s.Policy = p
+	}
+}
+
+func (p *Policy) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+// Authorize returns nil if the principal in ctx may perform action on c, and
+// an error wrapping ErrForbidden otherwise.
+func (p *Policy) Authorize(ctx context.Context, action Action, c Comment) error {
+	actor, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return ErrForbidden
+	}
+	if actor.HasRole(RoleAdmin) {
+		return nil
+	}
+
+	switch action {
+	case ActionUpdate, ActionDelete:
+		if actor.HasRole(RoleModerator) {
+			return nil
+		}
+		if c.Author == "" || c.Author != actor.Subject {
+			return ErrForbidden
+		}
+		if p.EditWindow > 0 && p.now().Sub(c.CreatedAt) > p.EditWindow {
+			return ErrEditWindowExpired
+		}
+		return nil
+	case ActionModerate:
+		if actor.HasRole(RoleModerator) {
+			return nil
+		}
+	case ActionViewHistory:
+		if actor.HasRole(RoleModerator) || (c.Author != "" && c.Author == actor.Subject) {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+func (s *Service) authorize(ctx context.Context, action Action, c Comment) error {
+	if s.Policy == nil {
+		if action == ActionManageDeleted || action == ActionViewAudit {
+			return ErrForbidden
+		}
+		return nil
+	}
+	return s.Policy.Authorize(ctx, action, c)
+}
@@ -0,0 +1,128 @@
+package comment
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+	"github.com/dvl-mukesh/go-workshop/internal/request"
+)
+
+// DefaultReactions are the reaction kinds offered unless WithReactions says
+// otherwise.
+var DefaultReactions = []string{"thumbs_up", "heart", "laugh", "hooray", "confused", "eyes"}
+
+var (
+	ErrInvalidReaction = Validation("invalid_reaction", "unsupported reaction")
+	ErrInvalidVote     = Validation("invalid_vote", "invalid vote, expected 1 or -1")
+)
+
+// Vote is one user's up (+1) or down (-1) vote on a comment.
+type Vote struct {
+	CommentID uint      `json:"comment_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID    string    `json:"user_id" gorm:"primaryKey"`
+	Value     int       `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Vote) TableName() string {
+	return "comment_votes"
+}
+
+// Reaction is one user's emoji reaction to a comment. Users may add several
+// kinds of reaction to the same comment, but each only once.
+type Reaction struct {
+	CommentID uint      `json:"comment_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID    string    `json:"user_id" gorm:"primaryKey"`
+	Kind      string    `json:"kind" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Reaction) TableName() string {
+	return "comment_reactions"
+}
+
+// WithReactions sets the reaction kinds users may add. An empty list keeps
+// the defaults.
+func WithReactions(kinds ...string) Option {
+	return func(s *Service) {
+		if len(kinds) > 0 {
+			s.Reactions = kinds
+		}
+	}
+}
+
+// ParseReactions splits a comma separated list of reaction kinds.
+func ParseReactions(s string) []string {
+	var kinds []string
+	for _, kind := range strings.Split(s, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// WilsonScore is the lower bound of the 95% Wilson score interval for the
+// share of upvotes. Unlike the raw ratio or difference it ranks a comment
+// with many votes above one with a handful, even at the same ratio.
+func WilsonScore(up, down int) float64 {
+	n := float64(up + down)
+	if n == 0 {
+		return 0
+	}
+
+	const z = 1.96
+	p := float64(up) / n
+	return (p + z*z/(2*n) - z*math.Sqrt((p*(1-p)+z*z/(4*n))/n)) / (1 + z*z/n)
+}
+
+// actorID identifies the caller for per-user records such as votes: their
+// subject or, for anonymous callers, their IP.
+func actorID(ctx context.Context) string {
+	if actor, ok := auth.PrincipalFromContext(ctx); ok {
+		return actor.Subject
+	}
+	return "ip:" + request.ClientIPFromContext(ctx)
+}
+
+// Vote records the caller's vote on a visible comment, replacing any earlier
+// vote. A value of 0 withdraws it.
+func (s *Service) Vote(ctx context.Context, ID uint, value int) (Comment, error) {
+	if value < -1 || value > 1 {
+		return Comment{}, ErrInvalidVote
+	}
+	if _, err := s.GetComment(ctx, ID); err != nil {
+		return Comment{}, err
+	}
+
+	voted, err := s.Store.SetVote(ctx, Vote{CommentID: ID, UserID: actorID(ctx), Value: value})
+	if err != nil {
+		return Comment{}, err
+	}
+
+	slog.DebugContext(ctx, "comment voted", "id", ID, "value", value, "score", voted.Score)
+	return voted, nil
+}
+
+// React adds (add true) or removes the caller's reaction of the given kind
+// on a visible comment. Both are idempotent.
+func (s *Service) React(ctx context.Context, ID uint, kind string, add bool) (Comment, error) {
+	if !slices.Contains(s.Reactions, kind) {
+		return Comment{}, ErrInvalidReaction
+	}
+	if _, err := s.GetComment(ctx, ID); err != nil {
+		return Comment{}, err
+	}
+
+	reaction := Reaction{CommentID: ID, UserID: actorID(ctx), Kind: kind}
+	if add {
+		return s.Store.AddReaction(ctx, reaction)
+	}
+	return s.Store.RemoveReaction(ctx, reaction)
+}
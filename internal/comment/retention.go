@@ -0,0 +1,138 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// DefaultRetentionBatchSize is how many comments Retention purges per store
+// call unless BatchSize says otherwise.
+const DefaultRetentionBatchSize = 500
+
+var (
+	ErrNotDeleted = Conflict("comment_not_deleted", "comment is not deleted")
+	ErrHasReplies = Conflict("comment_has_replies", "comment has replies and cannot be purged")
+)
+
+// ListDeletedComments returns one page of soft-deleted comments for admins.
+// Besides the usual orderings they may be sorted by deleted_at.
+func (s *Service) ListDeletedComments(ctx context.Context, opts ListOptions) (Page, error) {
+	if err := s.authorize(ctx, ActionManageDeleted, Comment{}); err != nil {
+		return Page{}, err
+	}
+	opts.Deleted = true
+	return s.listComments(ctx, opts)
+}
+
+// RestoreComment undeletes a soft-deleted comment.
+func (s *Service) RestoreComment(ctx context.Context, ID uint) (Comment, error) {
+	if err := s.checkDeleted(ctx, ID); err != nil {
+		return Comment{}, err
+	}
+
+	restored, err := s.Store.RestoreComment(ctx, ID, auditEntry(ctx, AuditRestore, ""))
+	if err != nil {
+		return Comment{}, err
+	}
+
+	slog.InfoContext(ctx, "comment restored", "id", ID)
+	return restored, nil
+}
+
+// PurgeComment permanently removes a soft-deleted comment along with its
+// revisions, votes, reactions and reports. Comments that still have
+// replies, deleted or not, are kept so threads keep their shape.
+func (s *Service) PurgeComment(ctx context.Context, ID uint) error {
+	if err := s.checkDeleted(ctx, ID); err != nil {
+		return err
+	}
+
+	if err := s.Store.PurgeComment(ctx, ID, auditEntry(ctx, AuditPurge, "")); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "comment purged", "id", ID)
+	return nil
+}
+
+// checkDeleted authorizes managing deleted comments and reports live ones
+// as ErrNotDeleted rather than not found.
+func (s *Service) checkDeleted(ctx context.Context, ID uint) error {
+	if err := s.authorize(ctx, ActionManageDeleted, Comment{}); err != nil {
+		return err
+	}
+	_, err := s.Store.GetComment(ctx, ID)
+	switch {
+	case err == nil:
+		return ErrNotDeleted
+	case errors.Is(err, ErrCommentNotFound):
+		return nil
+	}
+	return err
+}
+
+// Retention periodically purges comments that have been soft-deleted for
+// longer than MaxAge.
+type Retention struct {
+	Store     Store
+	MaxAge    time.Duration
+	Interval  time.Duration
+	BatchSize int
+	Now       func() time.Time
+}
+
+func (r *Retention) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// Purge runs one retention pass and returns how many comments it purged,
+// recording each in the audit log as purged by RetentionActor. Replies are
+// purged before their parents, so it keeps going until a batch comes back
+// empty rather than stopping at a short one.
+func (r *Retention) Purge(ctx context.Context) (int, error) {
+	batch := r.BatchSize
+	if batch <= 0 {
+		batch = DefaultRetentionBatchSize
+	}
+	before := r.now().Add(-r.MaxAge)
+
+	total := 0
+	for {
+		IDs, err := r.Store.PurgeDeletedComments(ctx, before, batch, AuditEntry{
+			Action:    AuditPurge,
+			Actor:     RetentionActor,
+			Reason:    "deleted for longer than " + r.MaxAge.String(),
+			CreatedAt: r.now(),
+		})
+		total += len(IDs)
+		if err != nil || len(IDs) == 0 {
+			return total, err
+		}
+	}
+}
+
+// Run purges once straight away and then every Interval until ctx is done.
+func (r *Retention) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := r.Purge(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "purging deleted comments", "purged", n, "err", err)
+		} else if n > 0 {
+			slog.InfoContext(ctx, "purged deleted comments", "purged", n, "max_age", r.MaxAge)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package comment
+
+import (
+	"context"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/diff"
+)
+
+var ErrRevisionNotFound = NotFound("revision_not_found", "revision not found")
+
+// Revision is an immutable version of a comment. Revision 1 is the comment
+// as first posted and each update adds the next one, recording who made it
+// and why.
+type Revision struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" gorm:"uniqueIndex:idx_comment_revisions_comment_number"`
+	Number    int       `json:"number" gorm:"uniqueIndex:idx_comment_revisions_comment_number"`
+	Slug      string    `json:"slug"`
+	Body      string    `json:"body"`
+	Author    string    `json:"author"`
+	Editor    string    `json:"editor,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Revision) TableName() string {
+	return "comment_revisions"
+}
+
+// Edit describes an update for the revision it creates.
+type Edit struct {
+	Editor string
+	Reason string
+	At     time.Time
+	// Moderation, when set, is a status change made along with the edit,
+	// such as holding it for review again.
+	Moderation *Moderation
+}
+
+// RevisionDiff compares two revisions of a comment. Body holds the
+// word-level changes from From to To.
+type RevisionDiff struct {
+	From Revision    `json:"from"`
+	To   Revision    `json:"to"`
+	Body []diff.Edit `json:"body"`
+}
+
+// canViewHistory checks the caller may see the edit history of a comment,
+// which can hold content later edited out.
+func (s *Service) canViewHistory(ctx context.Context, ID uint) error {
+	existing, err := s.GetComment(ctx, ID)
+	if err != nil {
+		return err
+	}
+	return s.authorize(ctx, ActionViewHistory, existing)
+}
+
+// GetRevisions returns every revision of a comment, oldest first. Comments
+// that were never edited have none.
+func (s *Service) GetRevisions(ctx context.Context, ID uint) ([]Revision, error) {
+	if err := s.canViewHistory(ctx, ID); err != nil {
+		return nil, err
+	}
+	return s.Store.ListRevisions(ctx, ID)
+}
+
+// DiffRevisions compares revisions from and to of a comment. Zero values
+// default to the latest revision and the one before it.
+func (s *Service) DiffRevisions(ctx context.Context, ID uint, from, to int) (RevisionDiff, error) {
+	if err := s.canViewHistory(ctx, ID); err != nil {
+		return RevisionDiff{}, err
+	}
+
+	revisions, err := s.Store.ListRevisions(ctx, ID)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+	if to == 0 {
+		to = len(revisions)
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	if from < 1 || from > len(revisions) || to < 1 || to > len(revisions) {
+		return RevisionDiff{}, ErrRevisionNotFound
+	}
+
+	a, b := revisions[from-1], revisions[to-1]
+	return RevisionDiff{
+		From: a,
+		To:   b,
+		Body: diff.Words(a.Body, b.Body),
+	}, nil
+}
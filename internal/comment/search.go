@@ -0,0 +1,199 @@
+package comment
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MaxSearchTerms bounds how many terms a search query may contain.
+const MaxSearchTerms = 16
+
+var (
+	ErrEmptySearch    = Validation("empty_search", "search query is empty")
+	ErrSearchTooLong  = Validation("search_too_long", "search query has too many terms")
+	ErrUnclosedPhrase = Validation("unclosed_phrase", "search query has an unclosed quote")
+)
+
+// Term is one search term: a single word or, when quoted, a phrase whose
+// words must appear next to each other. Prefix makes the last word match any
+// word starting with it.
+type Term struct {
+	Words  []string
+	Prefix bool
+}
+
+// ParseSearch splits a query into terms, all of which must match. Words are
+// lowercased and stripped of punctuation. "double quotes" group a phrase and
+// a trailing * turns a word into a prefix, for example:
+//
+//	"reply button" crash*
+func ParseSearch(q string) ([]Term, error) {
+	var terms []Term
+
+	for q = strings.TrimSpace(q); q != ""; q = strings.TrimSpace(q) {
+		var raw string
+		if q[0] == '"' {
+			end := strings.IndexByte(q[1:], '"')
+			if end < 0 {
+				return nil, ErrUnclosedPhrase
+			}
+			raw, q = q[1:end+1], q[end+2:]
+		} else {
+			end := strings.IndexFunc(q, unicode.IsSpace)
+			if end < 0 {
+				end = len(q)
+			}
+			raw, q = q[:end], q[end:]
+		}
+
+		// Punctuation inside a word, as in "e-mail", splits it into a phrase.
+		term := Term{
+			Words:  searchWords(raw),
+			Prefix: strings.HasSuffix(raw, "*"),
+		}
+		if len(term.Words) > 0 {
+			terms = append(terms, term)
+		}
+	}
+
+	if len(terms) == 0 {
+		return nil, ErrEmptySearch
+	}
+	if len(terms) > MaxSearchTerms {
+		return nil, ErrSearchTooLong
+	}
+	return terms, nil
+}
+
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// SearchOptions filters and pages a full-text search. The filters behave as
+// they do in ListOptions.
+type SearchOptions struct {
+	Query         string
+	Limit         int
+	Cursor        string
+	Slug          string
+	Author        string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// SearchQuery is a parsed search as issued to Store.SearchComments.
+type SearchQuery struct {
+	Terms         []Term
+	Statuses      []Status
+	Slug          string
+	Author        string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Offset        int
+	Limit         int
+}
+
+// SearchResult is a comment matching a search, with its relevance (higher is
+// better) and an HTML-escaped excerpt in which matches are wrapped in
+// HighlightStart and HighlightStop.
+type SearchResult struct {
+	Comment
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type SearchPage struct {
+	Items []SearchResult `json:"items"`
+	Page  PageInfo       `json:"page"`
+}
+
+// searchCursor is the position encoded into search next/prev tokens. Results
+// are ordered by rank, which isn't stable enough for keyset paging, so the
+// cursor records an offset and the query it belongs to.
+type searchCursor struct {
+	Offset int    `json:"o"`
+	Query  string `json:"q"`
+}
+
+func (c searchCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSearchCursor(s string) (searchCursor, error) {
+	var c searchCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.Offset < 0 {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// SearchComments returns one page of the comments matching opts.Query, most
+// relevant first. Moderators search every comment, others approved ones.
+func (s *Service) SearchComments(ctx context.Context, opts SearchOptions) (SearchPage, error) {
+	terms, err := ParseSearch(opts.Query)
+	if err != nil {
+		return SearchPage{}, err
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultPageSize
+	}
+	if opts.Limit > s.MaxPageSize {
+		opts.Limit = s.MaxPageSize
+	}
+
+	offset := 0
+	if opts.Cursor != "" {
+		c, err := decodeSearchCursor(opts.Cursor)
+		if err != nil {
+			return SearchPage{}, err
+		}
+		if c.Query != opts.Query {
+			return SearchPage{}, ErrInvalidCursor
+		}
+		offset = c.Offset
+	}
+
+	statuses := []Status{StatusApproved}
+	if isModerator(ctx) {
+		statuses = nil
+	}
+
+	results, err := s.Store.SearchComments(ctx, SearchQuery{
+		Terms:         terms,
+		Statuses:      statuses,
+		Slug:          opts.Slug,
+		Author:        opts.Author,
+		CreatedAfter:  opts.CreatedAfter,
+		CreatedBefore: opts.CreatedBefore,
+		Offset:        offset,
+		Limit:         opts.Limit + 1,
+	})
+	if err != nil {
+		return SearchPage{}, err
+	}
+
+	page := SearchPage{
+		Items: results,
+		Page:  PageInfo{Limit: opts.Limit},
+	}
+	if len(results) > opts.Limit {
+		page.Items = results[:opts.Limit]
+		page.Page.NextCursor = searchCursor{Offset: offset + opts.Limit, Query: opts.Query}.encode()
+	}
+	if offset > 0 {
+		page.Page.PrevCursor = searchCursor{Offset: max(offset-opts.Limit, 0), Query: opts.Query}.encode()
+	}
+	return page, nil
+}
@@ -0,0 +1,112 @@
+package comment
+
+import (
+	"context"
+	"time"
+)
+
+var ErrCommentNotFound = NotFound("comment_not_found", "comment not found")
+
+// Store persists comments for the Service. Implementations return
+// ErrCommentNotFound when no live comment has the requested ID.
+//
+// Creating, updating, deleting, moderating and restoring a comment also adds
+// the matching event from NewEvent to the outbox, atomically with the change.
+// Methods that take an AuditEntry append it to the audit log, completed with
+// Snapshot, in the same transaction, and fail if they cannot.
+type Store interface {
+	GetComment(ctx context.Context, ID uint) (Comment, error)
+	GetCommentsBySlug(ctx context.Context, slug string) ([]Comment, error)
+	CreateComment(ctx context.Context, comment Comment, entry AuditEntry) (Comment, error)
+	// UpdateComment overwrites the editable fields (slug, body, author) of
+	// the comment, including zero values, and marks it edited. In the same
+	// transaction it stores the new content as the next revision, preceded
+	// on the first edit by revision 1 holding the original, and applies
+	// edit.Moderation if set.
+	UpdateComment(ctx context.Context, ID uint, comment Comment, edit Edit, entry AuditEntry) (Comment, error)
+	// DeleteComment soft-deletes a live comment. Deleting a missing comment
+	// does nothing and records no entry.
+	DeleteComment(ctx context.Context, ID uint, entry AuditEntry) error
+	GetAllComments(ctx context.Context) ([]Comment, error)
+	ListComments(ctx context.Context, query ListQuery) ([]Comment, error)
+	// CountCommentsBySlug counts the approved comments on an article.
+	CountCommentsBySlug(ctx context.Context, slug string) (int64, error)
+	// GetThreadComments returns every comment, including soft-deleted ones,
+	// in the thread that contains ID.
+	GetThreadComments(ctx context.Context, ID uint) ([]Comment, error)
+	// SearchComments returns the live comments whose body matches every
+	// term, most relevant first.
+	SearchComments(ctx context.Context, query SearchQuery) ([]SearchResult, error)
+	// SetCommentStatus records a moderation decision on a live comment.
+	SetCommentStatus(ctx context.Context, ID uint, m Moderation, entry AuditEntry) (Comment, error)
+	// CreateReport stores a report and bumps the comment's report count,
+	// returning the report and the updated comment. It returns
+	// ErrAlreadyReported if the reporter has reported the comment before.
+	CreateReport(ctx context.Context, report Report) (Report, Comment, error)
+	ListReports(ctx context.Context, commentID uint) ([]Report, error)
+	RecordDecisions(ctx context.Context, decisions []Decision) error
+	ListDecisions(ctx context.Context, commentID uint) ([]Decision, error)
+	// SetVote stores or, for a zero value, removes a vote and returns the
+	// comment with its vote totals and score updated.
+	SetVote(ctx context.Context, vote Vote) (Comment, error)
+	// AddReaction and RemoveReaction are idempotent and return the comment
+	// with its reaction counts updated.
+	AddReaction(ctx context.Context, reaction Reaction) (Comment, error)
+	RemoveReaction(ctx context.Context, reaction Reaction) (Comment, error)
+	// ListRevisions returns a comment's revisions ordered by number.
+	ListRevisions(ctx context.Context, commentID uint) ([]Revision, error)
+	// RestoreComment undeletes a soft-deleted comment. It returns
+	// ErrCommentNotFound if no deleted comment has the ID.
+	RestoreComment(ctx context.Context, ID uint, entry AuditEntry) (Comment, error)
+	// PurgeComment hard-deletes a soft-deleted comment and the rows that
+	// belong to it, keeping its filter decisions with the comment ID
+	// cleared. It returns ErrCommentNotFound if no deleted comment has the
+	// ID and ErrHasReplies if any comment replies to it.
+	PurgeComment(ctx context.Context, ID uint, entry AuditEntry) error
+	// PurgeDeletedComments purges up to limit comments deleted before the
+	// given time that have no replies, returning the IDs it purged. Each
+	// purge is recorded with a copy of entry.
+	PurgeDeletedComments(ctx context.Context, before time.Time, limit int, entry AuditEntry) ([]uint, error)
+	// ListAudit scans the audit log, which is never updated or deleted from.
+	ListAudit(ctx context.Context, query AuditQuery) ([]AuditEntry, error)
+}
+
+// ListQuery is a single keyset scan over comments, as issued by
+// Service.ListComments.
+type ListQuery struct {
+	Slug          string
+	Author        string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Statuses, when set, only matches comments in these states.
+	Statuses []Status
+	// Deleted matches soft-deleted comments instead of live ones.
+	Deleted bool
+	SortBy  string
+	Desc    bool
+	// After, when set, only matches rows strictly after this position in
+	// the scan order.
+	After *Position
+	Limit int
+}
+
+// Position is a row's place in a (sort column, id) ordering. Value holds
+// the sort column for time orderings and Score for SortScore.
+type Position struct {
+	Value time.Time
+	Score float64
+	ID    uint
+}
+
+// Position returns c's place in the ordering by sortBy.
+func (c Comment) Position(sortBy string) Position {
+	switch sortBy {
+	case SortUpdatedAt:
+		return Position{Value: c.UpdatedAt, ID: c.ID}
+	case SortScore:
+		return Position{Score: c.Score, ID: c.ID}
+	case SortDeletedAt:
+		return Position{Value: c.DeletedAt.Time, ID: c.ID}
+	}
+	return Position{Value: c.CreatedAt, ID: c.ID}
+}
@@ -0,0 +1,104 @@
+package comment
+
+import "context"
+
+// ThreadNode is a comment together with its replies. Deleted comments, and
+// those the caller may not see because of moderation, are kept as tombstones
+// while they still have live replies so the thread keeps its shape.
+type ThreadNode struct {
+	Comment
+	Deleted bool          `json:"deleted"`
+	Hidden  bool          `json:"hidden,omitempty"`
+	Replies []*ThreadNode `json:"replies,omitempty"`
+}
+
+// GetThread returns the comment with the given ID and all of its replies as a
+// nested tree, descending at most maxDepth levels below it.
+func (s *Service) GetThread(ctx context.Context, ID uint, maxDepth int) (*ThreadNode, error) {
+	comments, err := s.Store.GetThreadComments(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden := func(c Comment) bool { return !s.canSee(ctx, c) }
+	node := buildThread(ID, comments, s.clampDepth(maxDepth), hidden)
+	if node == nil {
+		return nil, ErrCommentNotFound
+	}
+	return node, nil
+}
+
+// GetThreadFlat returns the same comments as GetThread in depth-first order,
+// each annotated with its depth, instead of nested.
+func (s *Service) GetThreadFlat(ctx context.Context, ID uint, maxDepth int) ([]ThreadNode, error) {
+	root, err := s.GetThread(ctx, ID, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	var flat []ThreadNode
+	var walk func(n *ThreadNode)
+	walk = func(n *ThreadNode) {
+		replies := n.Replies
+		node := *n
+		node.Replies = nil
+		flat = append(flat, node)
+		for _, r := range replies {
+			walk(r)
+		}
+	}
+	walk(root)
+
+	return flat, nil
+}
+
+func (s *Service) clampDepth(maxDepth int) int {
+	if maxDepth <= 0 || maxDepth > s.MaxDepth {
+		return s.MaxDepth
+	}
+	return maxDepth
+}
+
+func buildThread(ID uint, comments []Comment, maxDepth int, hidden func(Comment) bool) *ThreadNode {
+	children := make(map[uint][]Comment)
+	var start *Comment
+	for i := range comments {
+		c := comments[i]
+		if c.ID == ID {
+			start = &comments[i]
+		}
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+	if start == nil {
+		return nil
+	}
+
+	var build func(c Comment, level int) *ThreadNode
+	build = func(c Comment, level int) *ThreadNode {
+		node := &ThreadNode{Comment: c}
+		if level < maxDepth {
+			for _, child := range children[c.ID] {
+				if n := build(child, level+1); n != nil {
+					node.Replies = append(node.Replies, n)
+				}
+			}
+		}
+
+		if c.DeletedAt.Valid || hidden(c) {
+			truncated := level >= maxDepth && len(children[c.ID]) > 0
+			if len(node.Replies) == 0 && !truncated {
+				return nil
+			}
+			node.Deleted = c.DeletedAt.Valid
+			node.Hidden = !node.Deleted
+			node.Body = ""
+			node.Author = ""
+			node.ModerationReason = ""
+		}
+		return node
+	}
+
+	return build(*start, 0)
+}
@@ -1,10 +1,297 @@
 package config
 
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+
+	DefaultSQLitePath = "comments.db"
+
+	DefaultShutdownTimeout = 15
+
+	RateLimitStoreMemory   = "memory"
+	RateLimitStoreDatabase = "database"
+
+	DefaultRateLimitReads  = 300
+	DefaultRateLimitWrites = 30
+	DefaultRateLimitWindow = 60
+	DefaultRateLimitIP     = 600
+
+	DefaultLogLevel  = "info"
+	DefaultLogFormat = "json"
+
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+	TracingExporterFile   = "file"
+
+	DefaultTracingServiceName   = "comments-api"
+	DefaultTracingFile          = "traces.jsonl"
+	DefaultTracingSamplePercent = 100
+
+	CommentStatusApproved = "approved"
+	CommentStatusPending  = "pending"
+
+	FilterVerdictReject   = "reject"
+	FilterVerdictHold     = "hold"
+	FilterVerdictAnnotate = "annotate"
+
+	DefaultFilterFloodWindow = 60
+
+	DefaultRetentionInterval = 60
+
+	OutboxSinkLog     = "log"
+	OutboxSinkWebhook = "webhook"
+	OutboxSinkFile    = "file"
+
+	DefaultOutboxFile     = "outbox.jsonl"
+	DefaultOutboxInterval = 5
+)
+
 type Environment struct {
-	DbUserName string `env:"DB_USERNAME,required"`
-	DbPassword string `env:"DB_PASSWORD,required"`
-	DbHost     string `env:"DB_HOST,required"`
-	DbPort     string `env:"DB_PORT,required"`
-	DbName     string `env:"DB_NAME,required"`
+	DbDriver   string `env:"DB_DRIVER"`
+	DbUserName string `env:"DB_USERNAME"`
+	DbPassword string `env:"DB_PASSWORD"`
+	DbHost     string `env:"DB_HOST"`
+	DbPort     string `env:"DB_PORT"`
+	DbName     string `env:"DB_NAME"`
+	DbPath     string `env:"DB_PATH"`
 	Port       string `env:"COMMENT_SERVICE_PORT,required"`
+
+	CommentMaxDepth    int `env:"COMMENT_MAX_THREAD_DEPTH"`
+	CommentMaxPageSize int `env:"COMMENT_MAX_PAGE_SIZE"`
+	// MaxBodyBytes caps the size of request bodies.
+	MaxBodyBytes int `env:"MAX_BODY_BYTES"`
+
+	// ShutdownDelay is how long, in seconds, the server keeps serving after
+	// reporting not ready, so load balancers can stop routing to it.
+	ShutdownDelay int `env:"SHUTDOWN_DELAY_SECONDS"`
+	// ShutdownTimeout bounds, in seconds, how long in-flight requests may
+	// take to drain.
+	ShutdownTimeout int `env:"SHUTDOWN_TIMEOUT_SECONDS"`
+
+	AuthDisabled    bool   `env:"AUTH_DISABLED"`
+	AuthJWTSecret   string `env:"AUTH_JWT_SECRET"`
+	AuthJWKSFile    string `env:"AUTH_JWKS_FILE"`
+	AuthJWTIssuer   string `env:"AUTH_JWT_ISSUER"`
+	AuthJWTAudience string `env:"AUTH_JWT_AUDIENCE"`
+	// AuthAPIKeys is a comma separated list of key=subject[:role|role]
+	// entries.
+	AuthAPIKeys string `env:"AUTH_API_KEYS"`
+
+	// CommentEditWindow is how many minutes after posting authors may still
+	// edit or delete their comments. Zero means no limit.
+	CommentEditWindow int `env:"COMMENT_EDIT_WINDOW_MINUTES"`
+
+	// CommentDefaultStatus is the moderation status new comments start in:
+	// approved to publish them immediately or pending to hold them for
+	// review.
+	CommentDefaultStatus string `env:"COMMENT_DEFAULT_STATUS"`
+	// CommentReportThreshold is how many user reports flag a comment for
+	// review.
+	CommentReportThreshold int `env:"COMMENT_REPORT_THRESHOLD"`
+	// CommentReactions is a comma separated list of the reaction kinds users
+	// may add, replacing the built-in set.
+	CommentReactions string `env:"COMMENT_REACTIONS"`
+
+	// FilterBannedWordsFile lists banned words, one per line; lines
+	// starting with re: are regular expressions. FilterBannedVerdict is
+	// what happens to matching comments: reject, hold or annotate.
+	FilterBannedWordsFile string `env:"FILTER_BANNED_WORDS_FILE"`
+	FilterBannedVerdict   string `env:"FILTER_BANNED_VERDICT"`
+	// FilterMaxLinks holds comments with more links for moderation. Zero
+	// disables the check.
+	FilterMaxLinks int `env:"FILTER_MAX_LINKS"`
+	// FilterBlockedDomains is a comma separated list of domains comments
+	// may not link to.
+	FilterBlockedDomains string `env:"FILTER_BLOCKED_DOMAINS"`
+	// FilterFloodMax rejects authors posting this many comments within
+	// FilterFloodWindow seconds. Zero disables the check.
+	FilterFloodMax    int `env:"FILTER_FLOOD_MAX"`
+	FilterFloodWindow int `env:"FILTER_FLOOD_WINDOW_SECONDS"`
+	// FilterDuplicates rejects comments repeating one the same author posted
+	// within FilterFloodWindow seconds.
+	FilterDuplicates bool `env:"FILTER_REJECT_DUPLICATES"`
+
+	// CommentRetentionDays purges comments that have been deleted for this
+	// many days, checking every CommentRetentionInterval minutes. Zero keeps
+	// deleted comments until an admin purges them.
+	CommentRetentionDays     int `env:"COMMENT_RETENTION_DAYS"`
+	CommentRetentionInterval int `env:"COMMENT_RETENTION_INTERVAL_MINUTES"`
+
+	// OutboxSinks is a comma separated list of log, webhook and file: where
+	// comment events are relayed to. Events are recorded either way; without
+	// sinks they are pruned once a day old.
+	OutboxSinks         string `env:"OUTBOX_SINKS"`
+	OutboxWebhookURL    string `env:"OUTBOX_WEBHOOK_URL"`
+	OutboxWebhookSecret string `env:"OUTBOX_WEBHOOK_SECRET"`
+	OutboxFile          string `env:"OUTBOX_FILE"`
+	// OutboxInterval is how often, in seconds, the outbox is polled.
+	OutboxInterval int `env:"OUTBOX_POLL_INTERVAL_SECONDS"`
+
+	// TrustedProxies is a comma separated list of CIDRs whose
+	// X-Forwarded-For and X-Real-IP headers are trusted.
+	TrustedProxies string `env:"TRUSTED_PROXIES"`
+
+	RateLimitDisabled bool `env:"RATE_LIMIT_DISABLED"`
+	// RateLimitStore is "memory" (per replica) or "database" (shared through
+	// the configured database).
+	RateLimitStore  string `env:"RATE_LIMIT_STORE"`
+	RateLimitReads  int    `env:"RATE_LIMIT_READS"`
+	RateLimitWrites int    `env:"RATE_LIMIT_WRITES"`
+	RateLimitWindow int    `env:"RATE_LIMIT_WINDOW_SECONDS"`
+	// RateLimitIP caps all requests from one IP address per window,
+	// counted before authentication so bad credentials are throttled too.
+	RateLimitIP int `env:"RATE_LIMIT_IP"`
+
+	// LogLevel is one of debug, info, warn or error; debug includes every
+	// SQL query. LogFormat is json or text.
+	LogLevel  string `env:"LOG_LEVEL"`
+	LogFormat string `env:"LOG_FORMAT"`
+
+	// TracingExporter is otlp, stdout or file. Tracing is off when empty.
+	TracingExporter string `env:"TRACING_EXPORTER"`
+	// TracingOTLPEndpoint is the URL of the collector's OTLP/HTTP traces
+	// endpoint. When empty the standard OTEL_EXPORTER_OTLP_* variables apply.
+	TracingOTLPEndpoint  string `env:"TRACING_OTLP_ENDPOINT"`
+	TracingFile          string `env:"TRACING_FILE"`
+	TracingServiceName   string `env:"TRACING_SERVICE_NAME"`
+	TracingSamplePercent int    `env:"TRACING_SAMPLE_PERCENT"`
+}
+
+// Validate fills in defaults and checks that the settings required by the
+// selected DB driver are present.
+func (e *Environment) Validate() error {
+	if e.DbDriver == "" {
+		e.DbDriver = DriverPostgres
+	}
+	if e.ShutdownTimeout <= 0 {
+		e.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if e.LogLevel == "" {
+		e.LogLevel = DefaultLogLevel
+	}
+	if e.LogFormat == "" {
+		e.LogFormat = DefaultLogFormat
+	}
+	if e.RateLimitReads <= 0 {
+		e.RateLimitReads = DefaultRateLimitReads
+	}
+	if e.RateLimitWrites <= 0 {
+		e.RateLimitWrites = DefaultRateLimitWrites
+	}
+	if e.RateLimitWindow <= 0 {
+		e.RateLimitWindow = DefaultRateLimitWindow
+	}
+	if e.RateLimitIP <= 0 {
+		e.RateLimitIP = DefaultRateLimitIP
+	}
+
+	switch e.CommentDefaultStatus {
+	case "":
+		e.CommentDefaultStatus = CommentStatusApproved
+	case CommentStatusApproved, CommentStatusPending:
+	default:
+		return fmt.Errorf("unsupported COMMENT_DEFAULT_STATUS %q", e.CommentDefaultStatus)
+	}
+
+	switch e.FilterBannedVerdict {
+	case "":
+		e.FilterBannedVerdict = FilterVerdictReject
+	case FilterVerdictReject, FilterVerdictHold, FilterVerdictAnnotate:
+	default:
+		return fmt.Errorf("unsupported FILTER_BANNED_VERDICT %q", e.FilterBannedVerdict)
+	}
+	if e.FilterFloodWindow <= 0 {
+		e.FilterFloodWindow = DefaultFilterFloodWindow
+	}
+	if e.CommentRetentionInterval <= 0 {
+		e.CommentRetentionInterval = DefaultRetentionInterval
+	}
+
+	for _, sink := range e.OutboxSinkList() {
+		switch sink {
+		case OutboxSinkLog:
+		case OutboxSinkWebhook:
+			if e.OutboxWebhookURL == "" {
+				return fmt.Errorf("OUTBOX_WEBHOOK_URL is required for the webhook sink")
+			}
+		case OutboxSinkFile:
+			if e.OutboxFile == "" {
+				e.OutboxFile = DefaultOutboxFile
+			}
+		default:
+			return fmt.Errorf("unsupported outbox sink %q", sink)
+		}
+	}
+	if e.OutboxInterval <= 0 {
+		e.OutboxInterval = DefaultOutboxInterval
+	}
+
+	switch e.TracingExporter {
+	case "", TracingExporterOTLP, TracingExporterStdout:
+	case TracingExporterFile:
+		if e.TracingFile == "" {
+			e.TracingFile = DefaultTracingFile
+		}
+	default:
+		return fmt.Errorf("unsupported TRACING_EXPORTER %q", e.TracingExporter)
+	}
+	if e.TracingServiceName == "" {
+		e.TracingServiceName = DefaultTracingServiceName
+	}
+	if e.TracingSamplePercent <= 0 || e.TracingSamplePercent > 100 {
+		e.TracingSamplePercent = DefaultTracingSamplePercent
+	}
+
+	switch e.RateLimitStore {
+	case "":
+		e.RateLimitStore = RateLimitStoreMemory
+	case RateLimitStoreMemory:
+	case RateLimitStoreDatabase:
+		if e.DbDriver == DriverMemory {
+			return fmt.Errorf("RATE_LIMIT_STORE=%s needs a database, but DB_DRIVER is %s", RateLimitStoreDatabase, DriverMemory)
+		}
+	default:
+		return fmt.Errorf("unsupported RATE_LIMIT_STORE %q", e.RateLimitStore)
+	}
+
+	switch e.DbDriver {
+	case DriverPostgres:
+		required := map[string]string{
+			"DB_USERNAME": e.DbUserName,
+			"DB_PASSWORD": e.DbPassword,
+			"DB_HOST":     e.DbHost,
+			"DB_PORT":     e.DbPort,
+			"DB_NAME":     e.DbName,
+		}
+		for name, value := range required {
+			if value == "" {
+				return fmt.Errorf("required environment variable %s not found", name)
+			}
+		}
+	case DriverSQLite:
+		if e.DbPath == "" {
+			e.DbPath = DefaultSQLitePath
+		}
+	case DriverMemory:
+	default:
+		return fmt.Errorf("unsupported DB_DRIVER %q", e.DbDriver)
+	}
+	return nil
+}
+
+// OutboxSinkList returns the sink names in OutboxSinks.
+func (e *Environment) OutboxSinkList() []string {
+	var sinks []string
+	for _, sink := range strings.Split(e.OutboxSinks, ",") {
+		if sink = strings.TrimSpace(sink); sink != "" {
+			sinks = append(sinks, sink)
+		}
+	}
+	return sinks
 }
@@ -0,0 +1,222 @@
+// Package contentfilter provides the built-in comment.Filter
+// implementations: banned words and patterns, link limits, domain blocklists
+// and flood and duplicate detection.
+package contentfilter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+)
+
+// Words flags comments whose body contains a banned word or matches a
+// banned pattern.
+type Words struct {
+	Verdict comment.Verdict
+	entries []string
+	res     []*regexp.Regexp
+}
+
+// NewWords compiles a banned list. Entries prefixed with "re:" are regular
+// expressions; the others are words or phrases matched as whole words. Both
+// are case-insensitive.
+func NewWords(entries []string, verdict comment.Verdict) (*Words, error) {
+	w := &Words{Verdict: verdict}
+	for _, entry := range entries {
+		expr, isPattern := strings.CutPrefix(entry, "re:")
+		if !isPattern {
+			expr = `(^|[^\pL\pN])` + regexp.QuoteMeta(entry) + `($|[^\pL\pN])`
+		}
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("banned entry %q: %w", entry, err)
+		}
+		w.entries = append(w.entries, entry)
+		w.res = append(w.res, re)
+	}
+	return w, nil
+}
+
+// LoadList reads one entry per line from path, skipping blank lines and
+// lines starting with #.
+func LoadList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func (w *Words) Name() string { return "banned_words" }
+
+// Check reports every banned entry found in labels, which only moderators
+// see, and keeps the reason shown to the author generic.
+func (w *Words) Check(ctx context.Context, c comment.Comment) (comment.Decision, error) {
+	var labels []string
+	for i, re := range w.res {
+		if re.MatchString(c.Body) {
+			labels = append(labels, "banned:"+w.entries[i])
+		}
+	}
+	if len(labels) == 0 {
+		return comment.Decision{Verdict: comment.VerdictAllow}, nil
+	}
+	return comment.Decision{Verdict: w.Verdict, Reason: "contains banned language", Labels: labels}, nil
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"']+`)
+
+// links returns the URLs in s.
+func links(s string) []string {
+	return linkPattern.FindAllString(s, -1)
+}
+
+// LinkLimit holds comments with more than Max links for moderation.
+type LinkLimit struct {
+	Max int
+}
+
+func (l LinkLimit) Name() string { return "link_limit" }
+
+func (l LinkLimit) Check(ctx context.Context, c comment.Comment) (comment.Decision, error) {
+	if n := len(links(c.Body)); n > l.Max {
+		return comment.Decision{
+			Verdict: comment.VerdictHold,
+			Reason:  fmt.Sprintf("contains %d links, more than %d", n, l.Max),
+		}, nil
+	}
+	return comment.Decision{Verdict: comment.VerdictAllow}, nil
+}
+
+// DomainBlocklist rejects comments linking to any of Domains or their
+// subdomains.
+type DomainBlocklist struct {
+	Domains []string
+}
+
+func (b DomainBlocklist) Name() string { return "domain_blocklist" }
+
+func (b DomainBlocklist) Check(ctx context.Context, c comment.Comment) (comment.Decision, error) {
+	for _, link := range links(c.Body) {
+		if !strings.Contains(link, "://") {
+			link = "http://" + link
+		}
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+		for _, domain := range b.Domains {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return comment.Decision{
+					Verdict: comment.VerdictReject,
+					Reason:  "links to a blocked domain",
+					Labels:  []string{"domain:" + host},
+				}, nil
+			}
+		}
+	}
+	return comment.Decision{Verdict: comment.VerdictAllow}, nil
+}
+
+// ParseDomains splits a comma separated domain list, normalizing case.
+func ParseDomains(s string) []string {
+	var domains []string
+	for _, d := range strings.Split(s, ",") {
+		if d = strings.Trim(strings.ToLower(strings.TrimSpace(d)), "."); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
+
+// duplicateScan is how many recent comments Flood compares against when it
+// only checks for duplicates.
+const duplicateScan = 50
+
+// Flood rejects comments from authors who have posted Max or more comments
+// within Window and, with Duplicates, comments repeating one the author
+// posted within Window. Anonymous comments are not checked, and edits only
+// for duplicates of the author's other comments.
+type Flood struct {
+	Store      comment.Store
+	Window     time.Duration
+	Max        int
+	Duplicates bool
+	Now        func() time.Time
+}
+
+func (f *Flood) Name() string { return "flood" }
+
+func (f *Flood) now() time.Time {
+	if f.Now != nil {
+		return f.Now()
+	}
+	return time.Now()
+}
+
+func (f *Flood) Check(ctx context.Context, c comment.Comment) (comment.Decision, error) {
+	if c.Author == "" {
+		return comment.Decision{Verdict: comment.VerdictAllow}, nil
+	}
+
+	limit := f.Max
+	if f.Duplicates {
+		limit = max(limit, duplicateScan)
+	}
+	recent, err := f.Store.ListComments(ctx, comment.ListQuery{
+		Author:       c.Author,
+		CreatedAfter: f.now().Add(-f.Window),
+		SortBy:       comment.SortCreatedAt,
+		Desc:         true,
+		Limit:        limit,
+	})
+	if err != nil {
+		return comment.Decision{}, err
+	}
+
+	edit := c.ID != 0
+	if f.Max > 0 && !edit && len(recent) >= f.Max {
+		return comment.Decision{
+			Verdict: comment.VerdictReject,
+			Reason:  "posting too often, try again later",
+		}, nil
+	}
+	if f.Duplicates {
+		body := normalize(c.Body)
+		for _, r := range recent {
+			if r.ID != c.ID && normalize(r.Body) == body {
+				return comment.Decision{
+					Verdict: comment.VerdictReject,
+					Reason:  "duplicate of a recent comment",
+					Labels:  []string{fmt.Sprintf("duplicate:%d", r.ID)},
+				}, nil
+			}
+		}
+	}
+	return comment.Decision{Verdict: comment.VerdictAllow}, nil
+}
+
+// normalize folds case and whitespace so trivial variations of a comment
+// still count as duplicates.
+func normalize(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), unicode.IsSpace), " ")
+}
@@ -0,0 +1,152 @@
+package contentfilter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/contentfilter"
+	"github.com/dvl-mukesh/go-workshop/internal/database/memory"
+)
+
+var ctx = context.Background()
+
+func verdict(t *testing.T, f comment.Filter, body string) comment.Verdict {
+	t.Helper()
+
+	d, err := f.Check(ctx, comment.Comment{Author: "alice", Body: body})
+	if err != nil {
+		t.Fatalf("%s: %v", f.Name(), err)
+	}
+	return d.Verdict
+}
+
+func TestFilters(t *testing.T) {
+	words, err := contentfilter.NewWords([]string{"spam", `re:free\s+money`}, comment.VerdictHold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	domains := contentfilter.DomainBlocklist{Domains: contentfilter.ParseDomains(" Evil.example., ")}
+	linkLimit := contentfilter.LinkLimit{Max: 1}
+
+	tests := []struct {
+		filter comment.Filter
+		body   string
+		want   comment.Verdict
+	}{
+		{words, "This is SPAM!", comment.VerdictHold},
+		{words, "spammer", comment.VerdictAllow},
+		{words, "get FREE   money", comment.VerdictHold},
+		{domains, "see https://cdn.evil.example/x", comment.VerdictReject},
+		{domains, "see www.EVIL.example", comment.VerdictReject},
+		{domains, "see https://notevil.example", comment.VerdictAllow},
+		{linkLimit, "https://a.example", comment.VerdictAllow},
+		{linkLimit, "https://a.example and www.b.example", comment.VerdictHold},
+	}
+	for _, tt := range tests {
+		if got := verdict(t, tt.filter, tt.body); got != tt.want {
+			t.Errorf("%s(%q) = %s, want %s", tt.filter.Name(), tt.body, got, tt.want)
+		}
+	}
+
+	if _, err := contentfilter.NewWords([]string{"re:("}, comment.VerdictReject); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	store := memory.NewCommentStore()
+	words, _ := contentfilter.NewWords([]string{"darn"}, comment.VerdictHold)
+	s := comment.NewService(store, comment.WithFilters(
+		words,
+		&contentfilter.Flood{Store: store, Window: time.Minute, Max: 3, Duplicates: true},
+		comment.FilterFunc("classifier", func(ctx context.Context, c comment.Comment) (comment.Decision, error) {
+			return comment.Decision{Verdict: comment.VerdictAnnotate, Labels: []string{"lang:en"}}, nil
+		}),
+	))
+
+	held, err := s.PostComment(ctx, comment.Comment{Author: "alice", Body: "darn it"})
+	if err != nil {
+		t.Fatalf("PostComment: %v", err)
+	}
+	if held.Status != comment.StatusPending {
+		t.Errorf("status = %q, want pending", held.Status)
+	}
+	decisions, _ := store.ListDecisions(ctx, held.ID)
+	if len(decisions) != 2 || decisions[0].Filter != "banned_words" || decisions[1].Verdict != comment.VerdictAnnotate {
+		t.Errorf("decisions = %+v, want a hold and an annotation", decisions)
+	}
+
+	if _, err := s.PostComment(ctx, comment.Comment{Author: "alice", Body: "DARN  it"}); !errors.Is(err, comment.ErrContentRejected) {
+		t.Errorf("duplicate: got %v, want ErrContentRejected", err)
+	}
+
+	if _, err := s.PostComment(ctx, comment.Comment{Author: "alice", Body: "second"}); err != nil {
+		t.Fatalf("PostComment: %v", err)
+	}
+	if _, err := s.PostComment(ctx, comment.Comment{Author: "alice", Body: "third"}); err != nil {
+		t.Fatalf("PostComment: %v", err)
+	}
+	_, err = s.PostComment(ctx, comment.Comment{Author: "alice", Body: "fourth"})
+	if comment.AsError(err).Code != "content_rejected" {
+		t.Errorf("flood: got %v, want content_rejected", err)
+	}
+	if _, err := s.PostComment(ctx, comment.Comment{Author: "bob", Body: "fine"}); err != nil {
+		t.Errorf("other author: %v", err)
+	}
+}
+
+func TestEditsAreFiltered(t *testing.T) {
+	store := memory.NewCommentStore()
+	rejected, _ := contentfilter.NewWords([]string{"spam"}, comment.VerdictReject)
+	held, _ := contentfilter.NewWords([]string{"darn"}, comment.VerdictHold)
+	s := comment.NewService(store, comment.WithFilters(
+		rejected,
+		held,
+		&contentfilter.Flood{Store: store, Window: time.Minute, Max: 2, Duplicates: true},
+	))
+
+	first, err := s.PostComment(ctx, comment.Comment{Author: "alice", Body: "clean"})
+	if err != nil {
+		t.Fatalf("PostComment: %v", err)
+	}
+	second, err := s.PostComment(ctx, comment.Comment{Author: "alice", Body: "also clean"})
+	if err != nil {
+		t.Fatalf("PostComment: %v", err)
+	}
+
+	edit := first
+	edit.Body = "buy spam now"
+	if _, err := s.UpdateComment(ctx, first.ID, edit, ""); !errors.Is(err, comment.ErrContentRejected) {
+		t.Errorf("edit adding a rejected word: got %v, want ErrContentRejected", err)
+	}
+	if got, _ := s.GetComment(ctx, first.ID); got.Body != "clean" {
+		t.Errorf("body after rejected edit = %q, want it unchanged", got.Body)
+	}
+
+	edit.Body = "also  CLEAN"
+	if _, err := s.UpdateComment(ctx, first.ID, edit, ""); !errors.Is(err, comment.ErrContentRejected) {
+		t.Errorf("edit duplicating another comment: got %v, want ErrContentRejected", err)
+	}
+
+	// At the flood limit, editing is still allowed.
+	edit.Body = "darn, clean after all"
+	updated, err := s.UpdateComment(ctx, first.ID, edit, "")
+	if err != nil {
+		t.Fatalf("UpdateComment: %v", err)
+	}
+	if updated.Status != comment.StatusPending || updated.Body != edit.Body {
+		t.Errorf("edit adding a held word = %q %q, want pending", updated.Status, updated.Body)
+	}
+
+	second.Body = "ALSO clean"
+	if _, err := s.UpdateComment(ctx, second.ID, second, ""); err != nil {
+		t.Errorf("edit matching its own body: %v", err)
+	}
+	decisions, _ := store.ListDecisions(ctx, first.ID)
+	if len(decisions) != 3 {
+		t.Errorf("recorded %d decisions for the edits, want 3", len(decisions))
+	}
+}
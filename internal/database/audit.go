@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"gorm.io/gorm"
+)
+
+// appendAudit adds entry, completed for the comment with the given ID, to
+// the audit log inside the transaction making the change.
+func appendAudit(tx *gorm.DB, entry comment.AuditEntry, ID uint, before, after *comment.Comment) error {
+	entry = entry.Snapshot(ID, before, after)
+	return tx.Create(&entry).Error
+}
+
+func (s *CommentStore) ListAudit(ctx context.Context, q comment.AuditQuery) ([]comment.AuditEntry, error) {
+	query := s.DB.WithContext(ctx).Model(&comment.AuditEntry{})
+	if q.CommentID != 0 {
+		query = query.Where("comment_id = ?", q.CommentID)
+	}
+	if q.Actor != "" {
+		query = query.Where("actor = ?", q.Actor)
+	}
+	if q.Action != "" {
+		query = query.Where("action = ?", q.Action)
+	}
+	if !q.Since.IsZero() {
+		query = query.Where("created_at >= ?", q.Since)
+	}
+	if !q.Until.IsZero() {
+		query = query.Where("created_at < ?", q.Until)
+	}
+
+	op, dir := ">", "ASC"
+	if q.Desc {
+		op, dir = "<", "DESC"
+	}
+	if q.AfterID != 0 {
+		query = query.Where("id "+op+" ?", q.AfterID)
+	}
+
+	entries := []comment.AuditEntry{}
+	if result := query.Order("id " + dir).Limit(q.Limit).Find(&entries); result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	return entries, nil
+}
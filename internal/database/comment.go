@@ -0,0 +1,254 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"gorm.io/gorm"
+)
+
+// CommentStore is the gorm implementation of comment.Store.
+type CommentStore struct {
+	DB *gorm.DB
+}
+
+func NewCommentStore(db *gorm.DB) *CommentStore {
+	return &CommentStore{
+		DB: db,
+	}
+}
+
+// translate maps gorm errors to comment domain errors. Anything unexpected
+// is wrapped as internal so the cause is logged but never shown to clients.
+func translate(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return comment.ErrCommentNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fmt.Errorf("%w: %w", comment.ErrConflict, err)
+	default:
+		return comment.Internal(err)
+	}
+}
+
+func (s *CommentStore) GetComment(ctx context.Context, ID uint) (comment.Comment, error) {
+	var c comment.Comment
+	if result := s.DB.WithContext(ctx).First(&c, ID); result.Error != nil {
+		return comment.Comment{}, translate(result.Error)
+	}
+	return c, nil
+}
+
+func (s *CommentStore) GetCommentsBySlug(ctx context.Context, slug string) ([]comment.Comment, error) {
+	var comments []comment.Comment
+
+	if result := s.DB.WithContext(ctx).Where("slug = ?", slug).Find(&comments); result.Error != nil {
+		return comments, translate(result.Error)
+	}
+	return comments, nil
+}
+
+func (s *CommentStore) CreateComment(ctx context.Context, c comment.Comment, entry comment.AuditEntry) (comment.Comment, error) {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&c).Error; err != nil {
+			return err
+		}
+		if err := appendAudit(tx, entry, c.ID, nil, &c); err != nil {
+			return err
+		}
+		return s.addEvent(tx, comment.EventCommentCreated, c)
+	})
+	if err != nil {
+		return comment.Comment{}, translate(err)
+	}
+	return c, nil
+}
+
+func (s *CommentStore) UpdateComment(ctx context.Context, ID uint, newComment comment.Comment, edit comment.Edit, entry comment.AuditEntry) (comment.Comment, error) {
+	var c comment.Comment
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if c, err = lockComment(tx, ID); err != nil {
+			return err
+		}
+		before := c
+
+		revisions := []comment.Revision{{
+			CommentID: ID,
+			Number:    c.EditCount + 2,
+			Slug:      newComment.Slug,
+			Body:      newComment.Body,
+			Author:    newComment.Author,
+			Editor:    edit.Editor,
+			Reason:    edit.Reason,
+			CreatedAt: edit.At,
+		}}
+		if c.EditCount == 0 {
+			original := comment.Revision{
+				CommentID: ID,
+				Number:    1,
+				Slug:      c.Slug,
+				Body:      c.Body,
+				Author:    c.Author,
+				Editor:    c.Author,
+				CreatedAt: c.CreatedAt,
+			}
+			revisions = append([]comment.Revision{original}, revisions...)
+		}
+		if err := tx.Create(&revisions).Error; err != nil {
+			return err
+		}
+
+		newComment.Edited = true
+		newComment.EditCount = c.EditCount + 1
+		newComment.EditedAt = &edit.At
+
+		// Select the editable columns explicitly so zero values such as an
+		// empty body are written instead of being skipped by Updates.
+		columns := []string{"Slug", "Body", "Author", "Edited", "EditCount", "EditedAt"}
+		if m := edit.Moderation; m != nil {
+			newComment.Status = m.Status
+			newComment.ModerationReason = m.Reason
+			newComment.ModeratedBy = m.By
+			newComment.ModeratedAt = &m.At
+			columns = append(columns, "Status", "ModerationReason", "ModeratedBy", "ModeratedAt")
+		}
+		err = tx.Model(&c).Select(columns).Updates(newComment).Error
+		if err != nil {
+			return err
+		}
+		if err := appendAudit(tx, entry, ID, &before, &c); err != nil {
+			return err
+		}
+		return s.addEvent(tx, comment.EventCommentUpdated, c)
+	})
+	if err != nil {
+		return comment.Comment{}, translate(err)
+	}
+	return c, nil
+}
+
+func (s *CommentStore) DeleteComment(ctx context.Context, ID uint, entry comment.AuditEntry) error {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c, err := lockComment(tx, ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&c).Error; err != nil {
+			return err
+		}
+		if err := appendAudit(tx, entry, ID, &c, nil); err != nil {
+			return err
+		}
+		return s.addEvent(tx, comment.EventCommentDeleted, c)
+	})
+	if err != nil {
+		return translate(err)
+	}
+	return nil
+}
+
+func (s *CommentStore) GetAllComments(ctx context.Context) ([]comment.Comment, error) {
+	var comments []comment.Comment
+
+	if result := s.DB.WithContext(ctx).Find(&comments); result.Error != nil {
+		return comments, translate(result.Error)
+	}
+	return comments, nil
+}
+
+// filter applies the slug, author, status and creation time filters shared
+// by listing and search; zero values match everything.
+func filter(query *gorm.DB, slug, author string, statuses []comment.Status, after, before time.Time) *gorm.DB {
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	if slug != "" {
+		query = query.Where("slug = ?", slug)
+	}
+	if author != "" {
+		query = query.Where("author = ?", author)
+	}
+	if !after.IsZero() {
+		query = query.Where("created_at >= ?", after)
+	}
+	if !before.IsZero() {
+		query = query.Where("created_at < ?", before)
+	}
+	return query
+}
+
+func (s *CommentStore) ListComments(ctx context.Context, q comment.ListQuery) ([]comment.Comment, error) {
+	query := s.DB.WithContext(ctx).Model(&comment.Comment{})
+	if q.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	query = filter(query, q.Slug, q.Author, q.Statuses, q.CreatedAfter, q.CreatedBefore)
+
+	op, dir := ">", "ASC"
+	if q.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	// SortBy has already been checked against the allowed columns by the
+	// service, so it is safe to interpolate.
+	if q.After != nil {
+		var after any = q.After.Value
+		if q.SortBy == comment.SortScore {
+			after = q.After.Score
+		}
+		query = query.Where(
+			fmt.Sprintf("(%[1]s %[2]s ?) OR (%[1]s = ? AND id %[2]s ?)", q.SortBy, op),
+			after, after, q.After.ID,
+		)
+	}
+
+	comments := []comment.Comment{}
+	result := query.
+		Order(fmt.Sprintf("%s %s, id %s", q.SortBy, dir, dir)).
+		Limit(q.Limit).
+		Find(&comments)
+	if result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	return comments, nil
+}
+
+func (s *CommentStore) CountCommentsBySlug(ctx context.Context, slug string) (int64, error) {
+	var count int64
+	if result := s.DB.WithContext(ctx).Model(&comment.Comment{}).Where("slug = ? AND status = ?", slug, comment.StatusApproved).Count(&count); result.Error != nil {
+		return 0, translate(result.Error)
+	}
+	return count, nil
+}
+
+func (s *CommentStore) GetThreadComments(ctx context.Context, ID uint) ([]comment.Comment, error) {
+	var c comment.Comment
+	if result := s.DB.WithContext(ctx).Unscoped().First(&c, ID); result.Error != nil {
+		return nil, translate(result.Error)
+	}
+
+	rootID := c.ID
+	if c.RootID != nil {
+		rootID = *c.RootID
+	}
+
+	var comments []comment.Comment
+	result := s.DB.WithContext(ctx).Unscoped().
+		Where("id = ? OR root_id = ?", rootID, rootID).
+		Order("id").
+		Find(&comments)
+	if result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	return comments, nil
+}
@@ -2,7 +2,7 @@ package database
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/dvl-mukesh/go-workshop/internal/config"
 	"gorm.io/driver/postgres"
@@ -10,11 +10,28 @@ import (
 )
 
 func NewDatabase(env *config.Environment) (*gorm.DB, error) {
-	log.Println("Setting up new db connection")
-
-	connectString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", env.DbHost, env.DbPort, env.DbUserName, env.DbPassword, env.DbName)
+	slog.Info("Setting up new db connection", "driver", env.DbDriver)
+
+	var dialector gorm.Dialector
+
+	switch env.DbDriver {
+	case config.DriverSQLite:
+		d, err := sqliteDialector(env.DbPath)
+		if err != nil {
+			return nil, err
+		}
+		dialector = d
+	default:
+		connectString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", env.DbHost, env.DbPort, env.DbUserName, env.DbPassword, env.DbName)
+		dialector = postgres.Open(connectString)
+	}
 
-	db, err := gorm.Open(postgres.Open(connectString))
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: NewLogger(),
+		// Report constraint violations as gorm.ErrDuplicatedKey and friends
+		// instead of driver specific errors.
+		TranslateError: true,
+	})
 
 	if err != nil {
 		return nil, err
@@ -26,6 +43,12 @@ func NewDatabase(env *config.Environment) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if env.DbDriver == config.DriverSQLite {
+		// SQLite allows a single writer; funnel everything through one
+		// connection instead of failing with SQLITE_BUSY under load.
+		sqlDb.SetMaxOpenConns(1)
+	}
+
 	if err := sqlDb.Ping(); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,22 @@
+package database
+
+import (
+	"context"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+)
+
+func (s *CommentStore) RecordDecisions(ctx context.Context, decisions []comment.Decision) error {
+	if result := s.DB.WithContext(ctx).Create(&decisions); result.Error != nil {
+		return translate(result.Error)
+	}
+	return nil
+}
+
+func (s *CommentStore) ListDecisions(ctx context.Context, commentID uint) ([]comment.Decision, error) {
+	decisions := []comment.Decision{}
+	if result := s.DB.WithContext(ctx).Where("comment_id = ?", commentID).Order("id").Find(&decisions); result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	return decisions, nil
+}
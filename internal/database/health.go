@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/health"
+	"gorm.io/gorm"
+)
+
+// HealthCheck pings the database and reports the applied migration version
+// and connection pool statistics.
+func HealthCheck(db *gorm.DB) health.Checker {
+	return health.CheckerFunc(func(ctx context.Context) (map[string]any, error) {
+		sqlDb, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		err = sqlDb.PingContext(ctx)
+		ping := time.Since(start)
+
+		stats := sqlDb.Stats()
+		details := map[string]any{
+			"driver":          db.Dialector.Name(),
+			"ping_latency_ms": float64(ping.Microseconds()) / 1000,
+			"pool": map[string]any{
+				"max_open":       stats.MaxOpenConnections,
+				"open":           stats.OpenConnections,
+				"in_use":         stats.InUse,
+				"idle":           stats.Idle,
+				"wait_count":     stats.WaitCount,
+				"wait_duration":  stats.WaitDuration.String(),
+				"max_idle_close": stats.MaxIdleClosed,
+			},
+		}
+		if err != nil {
+			return details, err
+		}
+
+		if m, err := NewMigrator(db.WithContext(ctx)); err == nil {
+			if version, err := m.Version(); err == nil {
+				details["migration_version"] = version
+				details["migration_latest"] = m.Latest()
+			}
+		}
+		return details, nil
+	})
+}
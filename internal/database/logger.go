@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SlowQueryThreshold is how long a query may take before it is logged as a
+// warning.
+const SlowQueryThreshold = 200 * time.Millisecond
+
+// Logger sends gorm's logs to slog, so queries run on behalf of a request
+// carry its request ID. Every query is logged at debug level, slow queries
+// at warn and failures at error.
+type Logger struct {
+	level logger.LogLevel
+}
+
+func NewLogger() *Logger {
+	return &Logger{level: logger.Warn}
+}
+
+func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
+	return &Logger{level: level}
+}
+
+func (l *Logger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+
+	var level slog.Level
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level = slog.LevelError
+	case elapsed > SlowQueryThreshold:
+		level = slog.LevelWarn
+	default:
+		level = slog.LevelDebug
+	}
+
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Duration("duration", elapsed),
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.Any("err", err))
+	}
+	slog.Default().LogAttrs(ctx, level, "query", attrs...)
+}
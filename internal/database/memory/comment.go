@@ -0,0 +1,637 @@
+// Package memory provides in-memory implementations of the storage
+// interfaces, for local development and tests.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/outbox"
+	"gorm.io/gorm"
+)
+
+// CommentStore is a thread-safe, in-memory comment.Store. Deleted comments
+// are soft-deleted like they are with gorm.
+type CommentStore struct {
+	mu        sync.RWMutex
+	comments  map[uint]comment.Comment
+	nextID    uint
+	reports   []comment.Report
+	decisions []comment.Decision
+	votes     map[voteKey]comment.Vote
+	reactions map[comment.Reaction]bool
+	revisions []comment.Revision
+	audit     []comment.AuditEntry
+	events    []outbox.Event
+	nextEvent uint
+}
+
+type voteKey struct {
+	commentID uint
+	userID    string
+}
+
+func NewCommentStore() *CommentStore {
+	return &CommentStore{
+		comments:  make(map[uint]comment.Comment),
+		nextID:    1,
+		votes:     make(map[voteKey]comment.Vote),
+		reactions: make(map[comment.Reaction]bool),
+		nextEvent: 1,
+	}
+}
+
+func (s *CommentStore) live(ID uint) (comment.Comment, bool) {
+	c, ok := s.comments[ID]
+	if !ok || c.DeletedAt.Valid {
+		return comment.Comment{}, false
+	}
+	return c, true
+}
+
+// filter returns copies of the live comments that match keep, ordered by ID.
+func (s *CommentStore) filter(keep func(comment.Comment) bool) []comment.Comment {
+	return s.collect(func(c comment.Comment) bool {
+		return !c.DeletedAt.Valid && keep(c)
+	})
+}
+
+// deleted is filter for soft-deleted comments.
+func (s *CommentStore) deleted(keep func(comment.Comment) bool) []comment.Comment {
+	return s.collect(func(c comment.Comment) bool {
+		return c.DeletedAt.Valid && keep(c)
+	})
+}
+
+func (s *CommentStore) collect(keep func(comment.Comment) bool) []comment.Comment {
+	comments := []comment.Comment{}
+	for _, c := range s.comments {
+		if keep(c) {
+			comments = append(comments, c)
+		}
+	}
+	slices.SortFunc(comments, func(a, b comment.Comment) int {
+		return compareID(a.ID, b.ID)
+	})
+	return comments
+}
+
+func compareID(a, b uint) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func comparePosition(a, b comment.Position) int {
+	if c := a.Value.Compare(b.Value); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Score, b.Score); c != 0 {
+		return c
+	}
+	return compareID(a.ID, b.ID)
+}
+
+// matches applies the slug, author, status and creation time filters shared
+// by listing and search; zero values match everything.
+func matches(c comment.Comment, slug, author string, statuses []comment.Status, after, before time.Time) bool {
+	switch {
+	case len(statuses) > 0 && !slices.Contains(statuses, c.Status):
+		return false
+	case slug != "" && c.Slug != slug:
+		return false
+	case author != "" && c.Author != author:
+		return false
+	case !after.IsZero() && c.CreatedAt.Before(after):
+		return false
+	case !before.IsZero() && !c.CreatedAt.Before(before):
+		return false
+	}
+	return true
+}
+
+func (s *CommentStore) GetComment(ctx context.Context, ID uint) (comment.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.live(ID)
+	if !ok {
+		return comment.Comment{}, comment.ErrCommentNotFound
+	}
+	return c, nil
+}
+
+func (s *CommentStore) GetCommentsBySlug(ctx context.Context, slug string) ([]comment.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.filter(func(c comment.Comment) bool {
+		return c.Slug == slug
+	}), nil
+}
+
+func (s *CommentStore) CreateComment(ctx context.Context, c comment.Comment, entry comment.AuditEntry) (comment.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c.ID == 0 {
+		c.ID = s.nextID
+	}
+	if c.ID >= s.nextID {
+		s.nextID = c.ID + 1
+	}
+
+	if c.Status == "" {
+		c.Status = comment.StatusApproved
+	}
+
+	now := time.Now()
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = now
+	}
+	c.UpdatedAt = now
+
+	if err := s.save(c, comment.EventCommentCreated); err != nil {
+		return comment.Comment{}, err
+	}
+	s.appendAudit(entry, c.ID, nil, &c)
+	return c, nil
+}
+
+func (s *CommentStore) UpdateComment(ctx context.Context, ID uint, newComment comment.Comment, edit comment.Edit, entry comment.AuditEntry) (comment.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.live(ID)
+	if !ok {
+		return comment.Comment{}, comment.ErrCommentNotFound
+	}
+	before := c
+
+	if c.EditCount == 0 {
+		s.addRevision(comment.Revision{
+			CommentID: ID,
+			Number:    1,
+			Slug:      c.Slug,
+			Body:      c.Body,
+			Author:    c.Author,
+			Editor:    c.Author,
+			CreatedAt: c.CreatedAt,
+		})
+	}
+	s.addRevision(comment.Revision{
+		CommentID: ID,
+		Number:    c.EditCount + 2,
+		Slug:      newComment.Slug,
+		Body:      newComment.Body,
+		Author:    newComment.Author,
+		Editor:    edit.Editor,
+		Reason:    edit.Reason,
+		CreatedAt: edit.At,
+	})
+
+	c.Slug = newComment.Slug
+	c.Body = newComment.Body
+	c.Author = newComment.Author
+	c.Edited = true
+	c.EditCount++
+	c.EditedAt = &edit.At
+	c.UpdatedAt = time.Now()
+	if m := edit.Moderation; m != nil {
+		at := m.At
+		c.Status = m.Status
+		c.ModerationReason = m.Reason
+		c.ModeratedBy = m.By
+		c.ModeratedAt = &at
+	}
+
+	if err := s.save(c, comment.EventCommentUpdated); err != nil {
+		return comment.Comment{}, err
+	}
+	s.appendAudit(entry, ID, &before, &c)
+	return c, nil
+}
+
+func (s *CommentStore) DeleteComment(ctx context.Context, ID uint, entry comment.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.live(ID)
+	if !ok {
+		return nil
+	}
+	before := c
+
+	c.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	if err := s.save(c, comment.EventCommentDeleted); err != nil {
+		return err
+	}
+	s.appendAudit(entry, ID, &before, nil)
+	return nil
+}
+
+func (s *CommentStore) GetAllComments(ctx context.Context) ([]comment.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.filter(func(comment.Comment) bool { return true }), nil
+}
+
+func (s *CommentStore) ListComments(ctx context.Context, q comment.ListQuery) ([]comment.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keep := func(c comment.Comment) bool {
+		return matches(c, q.Slug, q.Author, q.Statuses, q.CreatedAfter, q.CreatedBefore)
+	}
+	comments := s.filter(keep)
+	if q.Deleted {
+		comments = s.deleted(keep)
+	}
+
+	order := func(a, b comment.Comment) int {
+		c := comparePosition(a.Position(q.SortBy), b.Position(q.SortBy))
+		if q.Desc {
+			return -c
+		}
+		return c
+	}
+	slices.SortFunc(comments, order)
+
+	if q.After != nil {
+		comments = slices.DeleteFunc(comments, func(c comment.Comment) bool {
+			cmp := comparePosition(c.Position(q.SortBy), *q.After)
+			if q.Desc {
+				return cmp >= 0
+			}
+			return cmp <= 0
+		})
+	}
+
+	if q.Limit > 0 && len(comments) > q.Limit {
+		comments = comments[:q.Limit]
+	}
+	return comments, nil
+}
+
+func (s *CommentStore) CountCommentsBySlug(ctx context.Context, slug string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comments := s.filter(func(c comment.Comment) bool {
+		return c.Slug == slug && c.Status == comment.StatusApproved
+	})
+	return int64(len(comments)), nil
+}
+
+func (s *CommentStore) GetThreadComments(ctx context.Context, ID uint) ([]comment.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.comments[ID]
+	if !ok {
+		return nil, comment.ErrCommentNotFound
+	}
+
+	rootID := c.ID
+	if c.RootID != nil {
+		rootID = *c.RootID
+	}
+
+	var comments []comment.Comment
+	for _, c := range s.comments {
+		if c.ID == rootID || (c.RootID != nil && *c.RootID == rootID) {
+			comments = append(comments, c)
+		}
+	}
+	slices.SortFunc(comments, func(a, b comment.Comment) int {
+		return compareID(a.ID, b.ID)
+	})
+	return comments, nil
+}
+
+func (s *CommentStore) SearchComments(ctx context.Context, q comment.SearchQuery) ([]comment.SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comments := s.filter(func(c comment.Comment) bool {
+		return matches(c, q.Slug, q.Author, q.Statuses, q.CreatedAfter, q.CreatedBefore)
+	})
+	return comment.RankSearch(comments, q), nil
+}
+
+func (s *CommentStore) SetCommentStatus(ctx context.Context, ID uint, m comment.Moderation, entry comment.AuditEntry) (comment.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.live(ID)
+	if !ok {
+		return comment.Comment{}, comment.ErrCommentNotFound
+	}
+	before := c
+
+	at := m.At
+	c.Status = m.Status
+	c.ModerationReason = m.Reason
+	c.ModeratedBy = m.By
+	c.ModeratedAt = &at
+
+	if err := s.save(c, comment.EventCommentUpdated); err != nil {
+		return comment.Comment{}, err
+	}
+	s.appendAudit(entry, ID, &before, &c)
+	return c, nil
+}
+
+func (s *CommentStore) CreateReport(ctx context.Context, r comment.Report) (comment.Report, comment.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.live(r.CommentID)
+	if !ok {
+		return comment.Report{}, comment.Comment{}, comment.ErrCommentNotFound
+	}
+	for _, existing := range s.reports {
+		if existing.CommentID == r.CommentID && existing.Reporter == r.Reporter {
+			return comment.Report{}, comment.Comment{}, comment.ErrAlreadyReported
+		}
+	}
+
+	r.ID = 1
+	if n := len(s.reports); n > 0 {
+		r.ID = s.reports[n-1].ID + 1
+	}
+	r.CreatedAt = time.Now()
+	s.reports = append(s.reports, r)
+
+	c.ReportCount++
+	s.comments[c.ID] = c
+	return r, c, nil
+}
+
+func (s *CommentStore) ListReports(ctx context.Context, commentID uint) ([]comment.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reports := []comment.Report{}
+	for _, r := range s.reports {
+		if r.CommentID == commentID {
+			reports = append(reports, r)
+		}
+	}
+	return reports, nil
+}
+
+func (s *CommentStore) RecordDecisions(ctx context.Context, decisions []comment.Decision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, d := range decisions {
+		d.ID = uint(len(s.decisions) + 1)
+		s.decisions = append(s.decisions, d)
+	}
+	return nil
+}
+
+func (s *CommentStore) ListDecisions(ctx context.Context, commentID uint) ([]comment.Decision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	decisions := []comment.Decision{}
+	for _, d := range s.decisions {
+		if d.CommentID != nil && *d.CommentID == commentID {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions, nil
+}
+
+func (s *CommentStore) SetVote(ctx context.Context, v comment.Vote) (comment.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.live(v.CommentID)
+	if !ok {
+		return comment.Comment{}, comment.ErrCommentNotFound
+	}
+
+	key := voteKey{v.CommentID, v.UserID}
+	if v.Value == 0 {
+		delete(s.votes, key)
+	} else {
+		now := time.Now()
+		v.CreatedAt, v.UpdatedAt = now, now
+		if old, ok := s.votes[key]; ok {
+			v.CreatedAt = old.CreatedAt
+		}
+		s.votes[key] = v
+	}
+
+	c.Upvotes, c.Downvotes = 0, 0
+	for key, vote := range s.votes {
+		switch {
+		case key.commentID != c.ID:
+		case vote.Value > 0:
+			c.Upvotes++
+		case vote.Value < 0:
+			c.Downvotes++
+		}
+	}
+	c.Score = comment.WilsonScore(c.Upvotes, c.Downvotes)
+
+	s.comments[c.ID] = c
+	return c, nil
+}
+
+func (s *CommentStore) AddReaction(ctx context.Context, r comment.Reaction) (comment.Comment, error) {
+	return s.react(r, true)
+}
+
+func (s *CommentStore) RemoveReaction(ctx context.Context, r comment.Reaction) (comment.Comment, error) {
+	return s.react(r, false)
+}
+
+func (s *CommentStore) react(r comment.Reaction, add bool) (comment.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.live(r.CommentID)
+	if !ok {
+		return comment.Comment{}, comment.ErrCommentNotFound
+	}
+
+	// Reactions are keyed without their timestamp.
+	r.CreatedAt = time.Time{}
+	if add {
+		s.reactions[r] = true
+	} else {
+		delete(s.reactions, r)
+	}
+
+	c.Reactions = nil
+	for r := range s.reactions {
+		if r.CommentID == c.ID {
+			if c.Reactions == nil {
+				c.Reactions = make(map[string]int)
+			}
+			c.Reactions[r.Kind]++
+		}
+	}
+
+	s.comments[c.ID] = c
+	return c, nil
+}
+
+func (s *CommentStore) addRevision(r comment.Revision) {
+	r.ID = 1
+	if n := len(s.revisions); n > 0 {
+		// Unlike len+1, this stays unique after purges.
+		r.ID = s.revisions[n-1].ID + 1
+	}
+	s.revisions = append(s.revisions, r)
+}
+
+func (s *CommentStore) ListRevisions(ctx context.Context, commentID uint) ([]comment.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := []comment.Revision{}
+	for _, r := range s.revisions {
+		if r.CommentID == commentID {
+			revisions = append(revisions, r)
+		}
+	}
+	return revisions, nil
+}
+
+func (s *CommentStore) RestoreComment(ctx context.Context, ID uint, entry comment.AuditEntry) (comment.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[ID]
+	if !ok || !c.DeletedAt.Valid {
+		return comment.Comment{}, comment.ErrCommentNotFound
+	}
+	before := c
+
+	c.DeletedAt = gorm.DeletedAt{}
+	if err := s.save(c, comment.EventCommentUpdated); err != nil {
+		return comment.Comment{}, err
+	}
+	s.appendAudit(entry, ID, &before, &c)
+	return c, nil
+}
+
+func (s *CommentStore) PurgeComment(ctx context.Context, ID uint, entry comment.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[ID]
+	if !ok || !c.DeletedAt.Valid {
+		return comment.ErrCommentNotFound
+	}
+	if s.hasReplies(ID) {
+		return comment.ErrHasReplies
+	}
+
+	s.purge(ID)
+	s.appendAudit(entry, ID, nil, nil)
+	return nil
+}
+
+func (s *CommentStore) PurgeDeletedComments(ctx context.Context, before time.Time, limit int, entry comment.AuditEntry) ([]uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := s.deleted(func(c comment.Comment) bool {
+		return c.DeletedAt.Time.Before(before) && !s.hasReplies(c.ID)
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	var IDs []uint
+	for _, c := range expired {
+		s.purge(c.ID)
+		s.appendAudit(entry, c.ID, nil, nil)
+		IDs = append(IDs, c.ID)
+	}
+	return IDs, nil
+}
+
+func (s *CommentStore) hasReplies(ID uint) bool {
+	for _, c := range s.comments {
+		if c.ParentID != nil && *c.ParentID == ID {
+			return true
+		}
+	}
+	return false
+}
+
+// purge removes a comment and everything recorded against it except filter
+// decisions, which are kept for audit without the comment ID.
+func (s *CommentStore) purge(ID uint) {
+	delete(s.comments, ID)
+
+	s.revisions = slices.DeleteFunc(s.revisions, func(r comment.Revision) bool { return r.CommentID == ID })
+	s.reports = slices.DeleteFunc(s.reports, func(r comment.Report) bool { return r.CommentID == ID })
+	for key := range s.votes {
+		if key.commentID == ID {
+			delete(s.votes, key)
+		}
+	}
+	for r := range s.reactions {
+		if r.CommentID == ID {
+			delete(s.reactions, r)
+		}
+	}
+	for i, d := range s.decisions {
+		if d.CommentID != nil && *d.CommentID == ID {
+			s.decisions[i].CommentID = nil
+		}
+	}
+}
+
+// appendAudit adds entry, completed for the comment with the given ID, to
+// the audit log. Callers hold s.mu.
+func (s *CommentStore) appendAudit(entry comment.AuditEntry, ID uint, before, after *comment.Comment) {
+	entry = entry.Snapshot(ID, before, after)
+	entry.ID = uint(len(s.audit) + 1)
+	s.audit = append(s.audit, entry)
+}
+
+func (s *CommentStore) ListAudit(ctx context.Context, q comment.AuditQuery) ([]comment.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := []comment.AuditEntry{}
+	for _, e := range s.audit {
+		switch {
+		case q.CommentID != 0 && e.CommentID != q.CommentID:
+		case q.Actor != "" && e.Actor != q.Actor:
+		case q.Action != "" && e.Action != q.Action:
+		case !q.Since.IsZero() && e.CreatedAt.Before(q.Since):
+		case !q.Until.IsZero() && !e.CreatedAt.Before(q.Until):
+		case q.AfterID != 0 && !q.Desc && e.ID <= q.AfterID:
+		case q.AfterID != 0 && q.Desc && e.ID >= q.AfterID:
+		default:
+			entries = append(entries, e)
+		}
+	}
+	if q.Desc {
+		slices.Reverse(entries)
+	}
+
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+	return entries, nil
+}
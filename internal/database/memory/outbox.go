@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/outbox"
+)
+
+// save stores c and adds the event announcing the change to the outbox.
+// Callers hold s.mu, which makes the two atomic.
+func (s *CommentStore) save(c comment.Comment, eventType string) error {
+	e, err := comment.NewEvent(eventType, c)
+	if err != nil {
+		return err
+	}
+	e.ID = s.nextEvent
+	s.nextEvent++
+
+	s.events = append(s.events, e)
+	s.comments[c.ID] = c
+	return nil
+}
+
+func (s *CommentStore) ClaimEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]outbox.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []outbox.Event
+	// Keys with an earlier pending event that is not due yet.
+	held := make(map[string]bool)
+	for i := range s.events {
+		if len(claimed) == limit {
+			break
+		}
+		e := &s.events[i]
+		if e.DeliveredAt != nil || e.DeadAt != nil || held[e.Key] {
+			continue
+		}
+		if e.NextAttemptAt.After(now) {
+			held[e.Key] = true
+			continue
+		}
+		e.NextAttemptAt = leaseUntil
+		claimed = append(claimed, *e)
+	}
+	return claimed, nil
+}
+
+func (s *CommentStore) MarkDelivered(ctx context.Context, ID uint, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e := s.event(ID); e != nil {
+		e.DeliveredAt = &at
+	}
+	return nil
+}
+
+func (s *CommentStore) MarkFailed(ctx context.Context, ID uint, next time.Time, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e := s.event(ID); e != nil {
+		e.Attempts++
+		e.NextAttemptAt = next
+		e.LastError = lastErr
+	}
+	return nil
+}
+
+func (s *CommentStore) MarkDead(ctx context.Context, ID uint, at time.Time, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e := s.event(ID); e != nil {
+		e.Attempts++
+		e.DeadAt = &at
+		e.LastError = lastErr
+	}
+	return nil
+}
+
+func (s *CommentStore) PruneDelivered(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.events)
+	s.events = slices.DeleteFunc(s.events, func(e outbox.Event) bool {
+		return (e.DeliveredAt != nil && e.DeliveredAt.Before(before)) ||
+			(e.DeadAt != nil && e.DeadAt.Before(before))
+	})
+	return int64(n - len(s.events)), nil
+}
+
+func (s *CommentStore) PruneUndelivered(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.events)
+	s.events = slices.DeleteFunc(s.events, func(e outbox.Event) bool {
+		return e.DeliveredAt == nil && e.DeadAt == nil && e.CreatedAt.Before(before)
+	})
+	return int64(n - len(s.events)), nil
+}
+
+func (s *CommentStore) event(ID uint) *outbox.Event {
+	for i := range s.events {
+		if s.events[i].ID == ID {
+			return &s.events[i]
+		}
+	}
+	return nil
+}
@@ -1,14 +1,366 @@
 package database
 
 import (
-	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
-func MigrateDB(db *gorm.DB) error {
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationLockID is the Postgres advisory lock key held while migrating, so
+// that replicas starting together don't race each other.
+const migrationLockID = 7_315_420_001
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration does not match its embedded source")
+	ErrUnknownVersion   = errors.New("unknown migration version")
+	ErrNoDownMigration  = errors.New("migration has no down script")
+)
+
+// Migration is one versioned schema change loaded from the embedded
+// migrations/<dialect> directory as NNNN_name.up.sql and NNNN_name.down.sql.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// SchemaMigration is a row in the schema_migrations table.
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    BIGINT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    checksum   TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`
 
-	if err := db.AutoMigrate(&comment.Comment{}); err != nil {
+// MigrationStatus describes a migration known to the binary or recorded in
+// the database.
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	// Modified is set when the embedded script no longer matches the
+	// checksum recorded when it was applied.
+	Modified bool `json:"modified,omitempty"`
+	// Unknown is set for versions applied by a newer binary.
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+type Migrator struct {
+	DB         *gorm.DB
+	Migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		DB:         db,
+		Migrations: migrations,
+	}, nil
+}
+
+func MigrateDB(db *gorm.DB) error {
+	m, err := NewMigrator(db)
+	if err != nil {
 		return err
 	}
+	return m.Up()
+}
+
+func loadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q: %w", dialect, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q", name)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			sum := sha256.Sum256(content)
+			m.Up = string(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+	return migrations, nil
+}
+
+// Latest returns the newest version known to the binary, or 0 if there are
+// none.
+func (m *Migrator) Latest() int {
+	if len(m.Migrations) == 0 {
+		return 0
+	}
+	return m.Migrations[len(m.Migrations)-1].Version
+}
+
+// Up applies every pending migration. Versions applied by a newer binary
+// are left in place, so that an older replica can still start.
+func (m *Migrator) Up() error {
+	return m.to(m.Latest(), false)
+}
+
+// Down rolls back the most recently applied migration. It fails with
+// ErrUnknownVersion when that migration was applied by a newer binary.
+func (m *Migrator) Down() error {
+	return m.withLock(func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			slog.Info("No migrations to roll back")
+			return nil
+		}
+
+		target := 0
+		if len(applied) > 1 {
+			target = applied[len(applied)-2].Version
+		}
+		if err := m.checkKnown(applied, target); err != nil {
+			return err
+		}
+		return m.migrate(db, applied, target)
+	})
+}
+
+// To migrates up or down until version is the newest applied migration. It
+// fails with ErrUnknownVersion when a newer binary applied migrations above
+// version, which this one cannot roll back.
+func (m *Migrator) To(version int) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	return m.to(version, true)
+}
+
+func (m *Migrator) to(version int, strict bool) error {
+	return m.withLock(func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		if strict {
+			if err := m.checkKnown(applied, version); err != nil {
+				return err
+			}
+		}
+		return m.migrate(db, applied, version)
+	})
+}
+
+func (m *Migrator) known(version int) bool {
+	return slices.ContainsFunc(m.Migrations, func(mig Migration) bool { return mig.Version == version })
+}
+
+// checkKnown returns an error if any migration applied above target is
+// unknown to the binary, so rolling back to target is impossible.
+func (m *Migrator) checkKnown(applied []SchemaMigration, target int) error {
+	for _, a := range applied {
+		if a.Version > target && !m.known(a.Version) {
+			return fmt.Errorf("%w: %04d_%s was applied by a newer binary and cannot be rolled back", ErrUnknownVersion, a.Version, a.Name)
+		}
+	}
+	return nil
+}
+
+// Version returns the newest applied migration version.
+func (m *Migrator) Version() (int, error) {
+	var version int
+	result := m.DB.Raw("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return version, nil
+}
+
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	if err := m.DB.Exec(createSchemaMigrations).Error; err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(m.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]SchemaMigration)
+	for _, a := range applied {
+		byVersion[a.Version] = a
+	}
+
+	var statuses []MigrationStatus
+	for _, mig := range m.Migrations {
+		status := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if a, ok := byVersion[mig.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &a.AppliedAt
+			status.Modified = a.Checksum != mig.Checksum
+			delete(byVersion, mig.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, a := range applied {
+		if _, ok := byVersion[a.Version]; ok {
+			statuses = append(statuses, MigrationStatus{
+				Version: a.Version, Name: a.Name,
+				Applied: true, AppliedAt: &a.AppliedAt, Unknown: true,
+			})
+		}
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) applied(db *gorm.DB) ([]SchemaMigration, error) {
+	var applied []SchemaMigration
+	if result := db.Order("version").Find(&applied); result.Error != nil {
+		return nil, result.Error
+	}
+	return applied, nil
+}
+
+// withLock runs fn on a single connection while holding the migration lock.
+func (m *Migrator) withLock(fn func(db *gorm.DB) error) error {
+	return m.DB.Connection(func(db *gorm.DB) error {
+		if db.Dialector.Name() == "postgres" {
+			if err := db.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+				return err
+			}
+			defer db.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+		}
+
+		if err := db.Exec(createSchemaMigrations).Error; err != nil {
+			return err
+		}
+		return fn(db)
+	})
+}
+
+func (m *Migrator) migrate(db *gorm.DB, applied []SchemaMigration, target int) error {
+	done := make(map[int]SchemaMigration)
+	for _, a := range applied {
+		done[a.Version] = a
+	}
+
+	for _, mig := range m.Migrations {
+		if a, ok := done[mig.Version]; ok && a.Checksum != mig.Checksum {
+			return fmt.Errorf("%w: %04d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+		}
+	}
+
+	for _, mig := range m.Migrations {
+		if _, ok := done[mig.Version]; ok || mig.Version > target {
+			continue
+		}
+
+		slog.Info("Applying migration", "version", mig.Version, "name", mig.Name)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(mig.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   mig.Version,
+				Name:      mig.Name,
+				Checksum:  mig.Checksum,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+	}
+
+	for i := len(m.Migrations) - 1; i >= 0; i-- {
+		mig := m.Migrations[i]
+		if _, ok := done[mig.Version]; !ok || mig.Version <= target {
+			continue
+		}
+		if mig.Down == "" {
+			return fmt.Errorf("%w: %04d_%s", ErrNoDownMigration, mig.Version, mig.Name)
+		}
+
+		slog.Info("Rolling back migration", "version", mig.Version, "name", mig.Name)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(mig.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, mig.Version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("rollback %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,132 @@
+//go:build sqlite
+
+package database_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/database"
+	"gorm.io/gorm"
+)
+
+func newMigrator(t *testing.T) (*database.Migrator, *gorm.DB) {
+	t.Helper()
+
+	db := newSQLite(t)
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	return m, db
+}
+
+func version(t *testing.T, m *database.Migrator) int {
+	t.Helper()
+
+	v, err := m.Version()
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	return v
+}
+
+func hasTable(db *gorm.DB, name string) bool {
+	return db.Migrator().HasTable(name)
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	m, db := newMigrator(t)
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if v := version(t, m); v != m.Latest() {
+		t.Errorf("version after Up = %d, want %d", v, m.Latest())
+	}
+	if !hasTable(db, "comments") || !hasTable(db, "outbox_events") {
+		t.Error("Up left tables out")
+	}
+	if err := m.Up(); err != nil {
+		t.Errorf("second Up: %v", err)
+	}
+
+	if err := m.To(9); err != nil {
+		t.Fatalf("To(9): %v", err)
+	}
+	if v := version(t, m); v != 9 || hasTable(db, "outbox_events") || !hasTable(db, "audit_log") {
+		t.Errorf("after To(9): version %d, outbox %v, audit %v", v, hasTable(db, "outbox_events"), hasTable(db, "audit_log"))
+	}
+
+	if err := m.Down(); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if v := version(t, m); v != 8 || hasTable(db, "audit_log") {
+		t.Errorf("after Down: version %d, audit %v", v, hasTable(db, "audit_log"))
+	}
+
+	if err := m.To(0); err != nil {
+		t.Fatalf("To(0): %v", err)
+	}
+	if v := version(t, m); v != 0 || hasTable(db, "comments") {
+		t.Errorf("after To(0): version %d, comments %v", v, hasTable(db, "comments"))
+	}
+	if err := m.Down(); err != nil {
+		t.Errorf("Down with nothing applied: %v", err)
+	}
+
+	if err := m.To(4); !errors.Is(err, database.ErrUnknownVersion) {
+		t.Errorf("To(4) = %v, want ErrUnknownVersion", err)
+	}
+}
+
+func TestMigrateChecksumMismatch(t *testing.T) {
+	m, db := newMigrator(t)
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	if err := db.Exec("UPDATE schema_migrations SET checksum = 'tampered' WHERE version = 2").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); !errors.Is(err, database.ErrChecksumMismatch) {
+		t.Errorf("Up = %v, want ErrChecksumMismatch", err)
+	}
+	if err := m.Down(); !errors.Is(err, database.ErrChecksumMismatch) {
+		t.Errorf("Down = %v, want ErrChecksumMismatch", err)
+	}
+	statuses, err := m.Status()
+	if err != nil || !statuses[1].Modified || statuses[0].Modified {
+		t.Errorf("Status = %+v, %v, want only 0002 modified", statuses, err)
+	}
+}
+
+func TestMigrateUnknownVersion(t *testing.T) {
+	m, db := newMigrator(t)
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	// A newer binary applied a migration this one doesn't have.
+	newer := database.SchemaMigration{Version: m.Latest() + 1, Name: "from_the_future", Checksum: "x", AppliedAt: time.Now()}
+	if err := db.Create(&newer).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Errorf("Up = %v, want the newer migration left alone", err)
+	}
+	if err := m.Down(); !errors.Is(err, database.ErrUnknownVersion) {
+		t.Errorf("Down = %v, want ErrUnknownVersion", err)
+	}
+	if err := m.To(m.Latest()); !errors.Is(err, database.ErrUnknownVersion) {
+		t.Errorf("To(%d) = %v, want ErrUnknownVersion", m.Latest(), err)
+	}
+	if v := version(t, m); v != newer.Version {
+		t.Errorf("version = %d, want %d untouched", v, newer.Version)
+	}
+	if !hasTable(db, "outbox_events") {
+		t.Error("a failed rollback dropped tables")
+	}
+}
@@ -0,0 +1,13 @@
+-- Matches the table gorm's AutoMigrate used to create, so existing
+-- deployments adopt the versioned migrations without changes.
+CREATE TABLE IF NOT EXISTS comments (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    slug       TEXT,
+    body       TEXT,
+    author     TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments (deleted_at);
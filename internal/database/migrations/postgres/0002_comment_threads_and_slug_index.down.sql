@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_comments_root_id;
+DROP INDEX IF EXISTS idx_comments_parent_id;
+DROP INDEX IF EXISTS idx_comments_slug;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS depth;
+ALTER TABLE comments DROP COLUMN IF EXISTS root_id;
+ALTER TABLE comments DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,7 @@
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS parent_id BIGINT;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS root_id BIGINT;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS depth BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_comments_slug ON comments (slug);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX IF NOT EXISTS idx_comments_root_id ON comments (root_id);
@@ -0,0 +1,6 @@
+-- Full-text search over comment bodies. The generated column keeps the
+-- vector in step with body without any application code.
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('english', COALESCE(body, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_comments_search_vector ON comments USING GIN (search_vector);
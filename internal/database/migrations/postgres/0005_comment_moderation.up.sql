@@ -0,0 +1,18 @@
+-- Existing comments were already public, so they start out approved.
+ALTER TABLE comments ADD COLUMN status TEXT NOT NULL DEFAULT 'approved';
+ALTER TABLE comments ADD COLUMN moderation_reason TEXT;
+ALTER TABLE comments ADD COLUMN moderated_by TEXT;
+ALTER TABLE comments ADD COLUMN moderated_at TIMESTAMPTZ;
+ALTER TABLE comments ADD COLUMN report_count BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_comments_status ON comments (status);
+
+CREATE TABLE IF NOT EXISTS comment_reports (
+    id         BIGSERIAL PRIMARY KEY,
+    comment_id BIGINT NOT NULL,
+    reporter   TEXT NOT NULL,
+    reason     TEXT,
+    created_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comment_reports_comment_reporter ON comment_reports (comment_id, reporter);
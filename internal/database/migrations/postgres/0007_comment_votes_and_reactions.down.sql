@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS comment_reactions;
+DROP TABLE IF EXISTS comment_votes;
+
+DROP INDEX IF EXISTS idx_comments_score;
+
+ALTER TABLE comments DROP COLUMN reactions;
+ALTER TABLE comments DROP COLUMN score;
+ALTER TABLE comments DROP COLUMN downvotes;
+ALTER TABLE comments DROP COLUMN upvotes;
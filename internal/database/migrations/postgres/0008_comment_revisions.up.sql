@@ -0,0 +1,18 @@
+ALTER TABLE comments ADD COLUMN edited BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE comments ADD COLUMN edit_count BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN edited_at TIMESTAMPTZ;
+
+-- Immutable versions of edited comments; revision 1 is the original.
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    id         BIGSERIAL PRIMARY KEY,
+    comment_id BIGINT NOT NULL,
+    number     BIGINT NOT NULL,
+    slug       TEXT,
+    body       TEXT,
+    author     TEXT,
+    editor     TEXT,
+    reason     TEXT,
+    created_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comment_revisions_comment_number ON comment_revisions (comment_id, number);
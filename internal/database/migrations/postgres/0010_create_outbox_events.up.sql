@@ -0,0 +1,16 @@
+-- Domain events written in the same transaction as the comment change they
+-- describe, waiting to be relayed to sinks.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              BIGSERIAL PRIMARY KEY,
+    type            TEXT NOT NULL,
+    key             TEXT NOT NULL,
+    payload         TEXT,
+    created_at      TIMESTAMPTZ,
+    attempts        BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    delivered_at    TIMESTAMPTZ,
+    last_error      TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (next_attempt_at, id) WHERE delivered_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_delivered_at ON outbox_events (delivered_at);
@@ -0,0 +1 @@
+-- Blanked bodies cannot be brought back, so there is nothing to undo.
@@ -0,0 +1,9 @@
+-- Audit snapshots no longer hold comment bodies, so that purging a comment
+-- removes its content for good. Blank the bodies recorded before then,
+-- lifting the append-only trigger for this one rewrite.
+ALTER TABLE audit_log DISABLE TRIGGER audit_log_append_only;
+
+UPDATE audit_log SET before = jsonb_set(before::jsonb, '{body}', '""')::text WHERE before IS NOT NULL;
+UPDATE audit_log SET after = jsonb_set(after::jsonb, '{body}', '""')::text WHERE after IS NOT NULL;
+
+ALTER TABLE audit_log ENABLE TRIGGER audit_log_append_only;
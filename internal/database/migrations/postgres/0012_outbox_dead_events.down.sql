@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_outbox_events_dead_at;
+DROP INDEX IF EXISTS idx_outbox_events_pending_key;
+DROP INDEX IF EXISTS idx_outbox_events_pending;
+
+ALTER TABLE outbox_events DROP COLUMN dead_at;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (next_attempt_at, id) WHERE delivered_at IS NULL;
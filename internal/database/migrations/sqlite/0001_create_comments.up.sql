@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS comments (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    slug       TEXT,
+    body       TEXT,
+    author     TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments (deleted_at);
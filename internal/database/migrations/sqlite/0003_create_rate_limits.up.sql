@@ -0,0 +1,8 @@
+CREATE TABLE rate_limits (
+    key          TEXT        NOT NULL,
+    window_start DATETIME    NOT NULL,
+    count        INTEGER     NOT NULL,
+    PRIMARY KEY (key, window_start)
+);
+
+CREATE INDEX idx_rate_limits_window_start ON rate_limits (window_start);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS comment_reports;
+
+DROP INDEX IF EXISTS idx_comments_status;
+
+ALTER TABLE comments DROP COLUMN report_count;
+ALTER TABLE comments DROP COLUMN moderated_at;
+ALTER TABLE comments DROP COLUMN moderated_by;
+ALTER TABLE comments DROP COLUMN moderation_reason;
+ALTER TABLE comments DROP COLUMN status;
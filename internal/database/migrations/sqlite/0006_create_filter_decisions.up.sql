@@ -0,0 +1,14 @@
+-- Content filter verdicts on new comments. comment_id is NULL for
+-- rejected comments, which are never stored.
+CREATE TABLE IF NOT EXISTS filter_decisions (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    comment_id INTEGER,
+    author     TEXT,
+    filter     TEXT NOT NULL,
+    verdict    TEXT NOT NULL,
+    reason     TEXT,
+    labels     TEXT,
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_filter_decisions_comment_id ON filter_decisions (comment_id);
@@ -0,0 +1,24 @@
+ALTER TABLE comments ADD COLUMN upvotes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN downvotes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN score REAL NOT NULL DEFAULT 0;
+-- JSON object of reaction kind to count.
+ALTER TABLE comments ADD COLUMN reactions TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_comments_score ON comments (score, id);
+
+CREATE TABLE IF NOT EXISTS comment_votes (
+    comment_id INTEGER NOT NULL,
+    user_id    TEXT NOT NULL,
+    value      INTEGER NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME,
+    PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS comment_reactions (
+    comment_id INTEGER NOT NULL,
+    user_id    TEXT NOT NULL,
+    kind       TEXT NOT NULL,
+    created_at DATETIME,
+    PRIMARY KEY (comment_id, user_id, kind)
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_comment_revisions_comment_number;
+DROP TABLE IF EXISTS comment_revisions;
+
+ALTER TABLE comments DROP COLUMN edited_at;
+ALTER TABLE comments DROP COLUMN edit_count;
+ALTER TABLE comments DROP COLUMN edited;
@@ -0,0 +1,13 @@
+-- Audit snapshots no longer hold comment bodies, so that purging a comment
+-- removes its content for good. Blank the bodies recorded before then,
+-- lifting the append-only trigger for this one rewrite.
+DROP TRIGGER IF EXISTS audit_log_no_update;
+
+UPDATE audit_log SET before = json_set(before, '$.body', '') WHERE before IS NOT NULL;
+UPDATE audit_log SET after = json_set(after, '$.body', '') WHERE after IS NOT NULL;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update
+    BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
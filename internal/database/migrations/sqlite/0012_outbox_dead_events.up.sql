@@ -0,0 +1,8 @@
+-- Events the relay gave up on after too many failed attempts.
+ALTER TABLE outbox_events ADD COLUMN dead_at DATETIME;
+
+DROP INDEX IF EXISTS idx_outbox_events_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (next_attempt_at, id) WHERE delivered_at IS NULL AND dead_at IS NULL;
+-- Finds earlier pending events for the same key, which hold later ones back.
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending_key ON outbox_events (key, id) WHERE delivered_at IS NULL AND dead_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_dead_at ON outbox_events (dead_at);
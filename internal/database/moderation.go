@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"gorm.io/gorm"
+)
+
+func (s *CommentStore) SetCommentStatus(ctx context.Context, ID uint, m comment.Moderation, entry comment.AuditEntry) (comment.Comment, error) {
+	var c comment.Comment
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if c, err = lockComment(tx, ID); err != nil {
+			return err
+		}
+		before := c
+
+		at := m.At
+		err = tx.Model(&c).
+			Select("Status", "ModerationReason", "ModeratedBy", "ModeratedAt").
+			Updates(comment.Comment{
+				Status:           m.Status,
+				ModerationReason: m.Reason,
+				ModeratedBy:      m.By,
+				ModeratedAt:      &at,
+			}).Error
+		if err != nil {
+			return err
+		}
+		if err := appendAudit(tx, entry, ID, &before, &c); err != nil {
+			return err
+		}
+		return s.addEvent(tx, comment.EventCommentUpdated, c)
+	})
+	if err != nil {
+		return comment.Comment{}, translate(err)
+	}
+	return c, nil
+}
+
+func (s *CommentStore) CreateReport(ctx context.Context, r comment.Report) (comment.Report, comment.Comment, error) {
+	var c comment.Comment
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&c, r.CommentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&r).Error; err != nil {
+			return err
+		}
+		c.ReportCount++
+		return tx.Model(&c).UpdateColumn("report_count", gorm.Expr("report_count + 1")).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return comment.Report{}, comment.Comment{}, comment.ErrAlreadyReported
+	}
+	if err != nil {
+		return comment.Report{}, comment.Comment{}, translate(err)
+	}
+	return r, c, nil
+}
+
+func (s *CommentStore) ListReports(ctx context.Context, commentID uint) ([]comment.Report, error) {
+	reports := []comment.Report{}
+	if result := s.DB.WithContext(ctx).Where("comment_id = ?", commentID).Order("id").Find(&reports); result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	return reports, nil
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/outbox"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// addEvent adds the event announcing a change to c to the outbox, inside the
+// transaction making the change.
+func (s *CommentStore) addEvent(tx *gorm.DB, eventType string, c comment.Comment) error {
+	e, err := comment.NewEvent(eventType, c)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&e).Error
+}
+
+// outboxLockClass namespaces the per-key advisory locks taken while
+// claiming outbox events on Postgres.
+const outboxLockClass = 7_315_420
+
+func (s *CommentStore) ClaimEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]outbox.Event, error) {
+	events := []outbox.Event{}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?", now).
+			Where(`NOT EXISTS (SELECT 1 FROM outbox_events earlier WHERE earlier.key = outbox_events.key
+				AND earlier.id < outbox_events.id AND earlier.delivered_at IS NULL AND earlier.dead_at IS NULL
+				AND earlier.next_attempt_at > ?)`, now).
+			Order("id").Limit(limit)
+		// Concurrent relays on Postgres claim disjoint keys; SQLite already
+		// serializes writers.
+		if tx.Dialector.Name() == "postgres" {
+			keys, err := lockKeys(tx, now, limit)
+			if err != nil || len(keys) == 0 {
+				return err
+			}
+			query = query.Where("key IN ?", keys).Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		if err := query.Find(&events).Error; err != nil || len(events) == 0 {
+			return err
+		}
+
+		IDs := make([]uint, len(events))
+		for i, e := range events {
+			IDs[i] = e.ID
+		}
+		return tx.Model(&outbox.Event{}).Where("id IN ?", IDs).UpdateColumn("next_attempt_at", leaseUntil).Error
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+	return events, nil
+}
+
+// lockKeys takes transaction-scoped advisory locks on the keys of up to
+// limit due events, skipping keys another relay is claiming. Row locks
+// alone are not enough: while another relay's claim is uncommitted, the
+// guard against overtaking an earlier event still sees that event as due,
+// not leased. Once the key's lock is held, every earlier claim on it has
+// committed and is visible to the queries that follow.
+func lockKeys(tx *gorm.DB, now time.Time, limit int) ([]string, error) {
+	var keys []string
+	err := tx.Raw(`SELECT key FROM (
+			SELECT key, MIN(id) AS first FROM outbox_events
+			WHERE delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?
+			GROUP BY key ORDER BY first LIMIT ?
+		) due WHERE pg_try_advisory_xact_lock(?, hashtext(key))`, now, limit, outboxLockClass).Scan(&keys).Error
+	return keys, err
+}
+
+func (s *CommentStore) MarkDelivered(ctx context.Context, ID uint, at time.Time) error {
+	result := s.DB.WithContext(ctx).Model(&outbox.Event{}).Where("id = ?", ID).UpdateColumn("delivered_at", at)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	return nil
+}
+
+func (s *CommentStore) MarkFailed(ctx context.Context, ID uint, next time.Time, lastErr string) error {
+	result := s.DB.WithContext(ctx).Model(&outbox.Event{}).Where("id = ?", ID).UpdateColumns(map[string]any{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"next_attempt_at": next,
+		"last_error":      lastErr,
+	})
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	return nil
+}
+
+func (s *CommentStore) MarkDead(ctx context.Context, ID uint, at time.Time, lastErr string) error {
+	result := s.DB.WithContext(ctx).Model(&outbox.Event{}).Where("id = ?", ID).UpdateColumns(map[string]any{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"dead_at":    at,
+		"last_error": lastErr,
+	})
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	return nil
+}
+
+func (s *CommentStore) PruneDelivered(ctx context.Context, before time.Time) (int64, error) {
+	result := s.DB.WithContext(ctx).Where("delivered_at < ? OR dead_at < ?", before, before).Delete(&outbox.Event{})
+	if result.Error != nil {
+		return 0, translate(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (s *CommentStore) PruneUndelivered(ctx context.Context, before time.Time) (int64, error) {
+	result := s.DB.WithContext(ctx).Where("delivered_at IS NULL AND dead_at IS NULL AND created_at < ?", before).Delete(&outbox.Event{})
+	if result.Error != nil {
+		return 0, translate(result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/ratelimit"
+	"gorm.io/gorm"
+)
+
+// cleanupEvery is how many Allow calls pass between purges of expired
+// rate limit windows.
+const cleanupEvery = 1000
+
+// RateLimitStore is a ratelimit.Store backed by the rate_limits table so
+// that every replica shares the same counters. It uses a sliding window
+// counter: the previous window's count is weighted by how much of it still
+// overlaps the sliding window. Rejected requests are counted too, so clients
+// that keep hammering stay throttled.
+type RateLimitStore struct {
+	DB *gorm.DB
+
+	calls atomic.Uint64
+	now   func() time.Time
+}
+
+func NewRateLimitStore(db *gorm.DB) *RateLimitStore {
+	return &RateLimitStore{
+		DB:  db,
+		now: time.Now,
+	}
+}
+
+func (s *RateLimitStore) Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	db := s.DB.WithContext(ctx)
+
+	now := s.now().UTC()
+	start := now.Truncate(limit.Window)
+	elapsed := now.Sub(start)
+
+	var current int
+	result := db.Raw(`INSERT INTO rate_limits (key, window_start, count) VALUES (?, ?, 1)
+		ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limits.count + 1
+		RETURNING count`, key, start).Scan(&current)
+	if result.Error != nil {
+		return ratelimit.Result{}, result.Error
+	}
+
+	var previous int
+	result = db.Raw("SELECT count FROM rate_limits WHERE key = ? AND window_start = ?", key, start.Add(-limit.Window)).Scan(&previous)
+	if result.Error != nil {
+		return ratelimit.Result{}, result.Error
+	}
+
+	if s.calls.Add(1)%cleanupEvery == 0 {
+		db.Exec("DELETE FROM rate_limits WHERE window_start < ?", start.Add(-limit.Window))
+	}
+
+	weight := 1 - elapsed.Seconds()/limit.Window.Seconds()
+	used := float64(previous)*weight + float64(current)
+	remaining := float64(limit.Requests) - used
+
+	res := ratelimit.Result{
+		Allowed:   remaining >= 0,
+		Limit:     limit.Requests,
+		Remaining: int(math.Max(0, math.Floor(remaining))),
+		Reset:     limit.Window - elapsed,
+	}
+	if !res.Allowed {
+		res.RetryAfter = retryAfter(previous, current, limit, elapsed)
+	}
+	return res, nil
+}
+
+// retryAfter estimates how long until the weighted previous window has decayed
+// enough to allow one more request, assuming no further traffic.
+func retryAfter(previous, current int, limit ratelimit.Limit, elapsed time.Duration) time.Duration {
+	untilNextWindow := limit.Window - elapsed
+	if previous == 0 || current >= limit.Requests {
+		return untilNextWindow
+	}
+
+	// Solve previous*(1 - t/window) + current <= limit - 1 for t.
+	t := limit.Window.Seconds() * (1 - float64(limit.Requests-1-current)/float64(previous))
+	wait := time.Duration(t*float64(time.Second)) - elapsed
+	return min(max(wait, time.Second), untilNextWindow)
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lockComment loads a live comment inside tx, locking its row on Postgres so
+// concurrent votes and edits that build on its current state apply one at a
+// time. SQLite already serializes writers.
+func lockComment(tx *gorm.DB, ID uint) (comment.Comment, error) {
+	var c comment.Comment
+	if tx.Dialector.Name() == "postgres" {
+		tx = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	err := tx.First(&c, ID).Error
+	return c, err
+}
+
+func (s *CommentStore) SetVote(ctx context.Context, v comment.Vote) (comment.Comment, error) {
+	var c comment.Comment
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if c, err = lockComment(tx, v.CommentID); err != nil {
+			return err
+		}
+
+		if v.Value == 0 {
+			err = tx.Where("comment_id = ? AND user_id = ?", v.CommentID, v.UserID).Delete(&comment.Vote{}).Error
+		} else {
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "comment_id"}, {Name: "user_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+			}).Create(&v).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		var totals struct{ Up, Down int }
+		err = tx.Model(&comment.Vote{}).
+			Select("COALESCE(SUM(CASE WHEN value > 0 THEN 1 ELSE 0 END), 0) AS up, COALESCE(SUM(CASE WHEN value < 0 THEN 1 ELSE 0 END), 0) AS down").
+			Where("comment_id = ?", v.CommentID).
+			Scan(&totals).Error
+		if err != nil {
+			return err
+		}
+
+		// UpdateColumns leaves updated_at alone: votes aren't edits.
+		c.Upvotes, c.Downvotes = totals.Up, totals.Down
+		c.Score = comment.WilsonScore(totals.Up, totals.Down)
+		return tx.Model(&c).UpdateColumns(map[string]any{
+			"upvotes":   c.Upvotes,
+			"downvotes": c.Downvotes,
+			"score":     c.Score,
+		}).Error
+	})
+	if err != nil {
+		return comment.Comment{}, translate(err)
+	}
+	return c, nil
+}
+
+func (s *CommentStore) AddReaction(ctx context.Context, r comment.Reaction) (comment.Comment, error) {
+	return s.react(ctx, r, func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&r).Error
+	})
+}
+
+func (s *CommentStore) RemoveReaction(ctx context.Context, r comment.Reaction) (comment.Comment, error) {
+	return s.react(ctx, r, func(tx *gorm.DB) error {
+		return tx.Where("comment_id = ? AND user_id = ? AND kind = ?", r.CommentID, r.UserID, r.Kind).Delete(&comment.Reaction{}).Error
+	})
+}
+
+// react applies change to the reactions on a comment and recounts them.
+func (s *CommentStore) react(ctx context.Context, r comment.Reaction, change func(tx *gorm.DB) error) (comment.Comment, error) {
+	var c comment.Comment
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if c, err = lockComment(tx, r.CommentID); err != nil {
+			return err
+		}
+		if err := change(tx); err != nil {
+			return err
+		}
+
+		var counts []struct {
+			Kind  string
+			Count int
+		}
+		err = tx.Model(&comment.Reaction{}).
+			Select("kind, COUNT(*) AS count").
+			Where("comment_id = ?", r.CommentID).
+			Group("kind").
+			Scan(&counts).Error
+		if err != nil {
+			return err
+		}
+
+		c.Reactions = nil
+		for _, kc := range counts {
+			if c.Reactions == nil {
+				c.Reactions = make(map[string]int)
+			}
+			c.Reactions[kc.Kind] = kc.Count
+		}
+		return tx.Model(&c).Select("Reactions").UpdateColumns(comment.Comment{Reactions: c.Reactions}).Error
+	})
+	if err != nil {
+		return comment.Comment{}, translate(err)
+	}
+	return c, nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// deletedComments scopes tx to soft-deleted comments, locking the rows it
+// reads on Postgres so they can't be restored mid-purge.
+func deletedComments(tx *gorm.DB) *gorm.DB {
+	tx = tx.Unscoped().Model(&comment.Comment{}).Where("deleted_at IS NOT NULL")
+	if tx.Dialector.Name() == "postgres" {
+		tx = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	return tx
+}
+
+func (s *CommentStore) RestoreComment(ctx context.Context, ID uint, entry comment.AuditEntry) (comment.Comment, error) {
+	var c comment.Comment
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deletedComments(tx).First(&c, ID).Error; err != nil {
+			return err
+		}
+		before := c
+		c.DeletedAt = gorm.DeletedAt{}
+		if err := tx.Unscoped().Model(&c).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := appendAudit(tx, entry, ID, &before, &c); err != nil {
+			return err
+		}
+		return s.addEvent(tx, comment.EventCommentUpdated, c)
+	})
+	if err != nil {
+		return comment.Comment{}, translate(err)
+	}
+	return c, nil
+}
+
+func (s *CommentStore) PurgeComment(ctx context.Context, ID uint, entry comment.AuditEntry) error {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var c comment.Comment
+		if err := deletedComments(tx).First(&c, ID).Error; err != nil {
+			return err
+		}
+
+		var replies int64
+		if err := tx.Unscoped().Model(&comment.Comment{}).Where("parent_id = ?", ID).Count(&replies).Error; err != nil {
+			return err
+		}
+		if replies > 0 {
+			return comment.ErrHasReplies
+		}
+
+		if err := purge(tx, []uint{ID}); err != nil {
+			return err
+		}
+		return appendAudit(tx, entry, ID, nil, nil)
+	})
+	if err != nil {
+		return translate(err)
+	}
+	return nil
+}
+
+func (s *CommentStore) PurgeDeletedComments(ctx context.Context, before time.Time, limit int, entry comment.AuditEntry) ([]uint, error) {
+	var IDs []uint
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := deletedComments(tx).
+			Where("deleted_at < ?", before).
+			Where("NOT EXISTS (SELECT 1 FROM comments AS replies WHERE replies.parent_id = comments.id)").
+			Order("id").
+			Limit(limit).
+			Pluck("id", &IDs).Error
+		if err != nil || len(IDs) == 0 {
+			return err
+		}
+		if err := purge(tx, IDs); err != nil {
+			return err
+		}
+		entries := make([]comment.AuditEntry, len(IDs))
+		for i, ID := range IDs {
+			entries[i] = entry.Snapshot(ID, nil, nil)
+		}
+		return tx.Create(&entries).Error
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+	return IDs, nil
+}
+
+// purge hard-deletes comments and everything recorded against them except
+// filter decisions, which are kept for audit without the comment ID.
+func purge(tx *gorm.DB, IDs []uint) error {
+	for _, model := range []any{&comment.Revision{}, &comment.Vote{}, &comment.Reaction{}, &comment.Report{}} {
+		if err := tx.Where("comment_id IN ?", IDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	err := tx.Model(&comment.Decision{}).Where("comment_id IN ?", IDs).UpdateColumn("comment_id", nil).Error
+	if err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&comment.Comment{}, IDs).Error
+}
@@ -0,0 +1,15 @@
+package database
+
+import (
+	"context"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+)
+
+func (s *CommentStore) ListRevisions(ctx context.Context, commentID uint) ([]comment.Revision, error) {
+	revisions := []comment.Revision{}
+	if result := s.DB.WithContext(ctx).Where("comment_id = ?", commentID).Order("number").Find(&revisions); result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	return revisions, nil
+}
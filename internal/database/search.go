@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+)
+
+// headlineOptions configures ts_headline to produce snippets like
+// comment.MatchSearch does.
+var headlineOptions = fmt.Sprintf(
+	"StartSel=%s, StopSel=%s, MaxWords=30, MinWords=15",
+	comment.HighlightStart, comment.HighlightStop,
+)
+
+// escapedBody is the comment body HTML-escaped in SQL, so that snippets are
+// safe to render once the highlight markers have been added.
+const escapedBody = `replace(replace(replace(body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')`
+
+// SearchComments uses the search_vector column and its GIN index on
+// Postgres. Other databases have no full-text index, so candidates are
+// narrowed down with LIKE and then ranked by comment.RankSearch.
+func (s *CommentStore) SearchComments(ctx context.Context, q comment.SearchQuery) ([]comment.SearchResult, error) {
+	query := filter(s.DB.WithContext(ctx).Model(&comment.Comment{}), q.Slug, q.Author, q.Statuses, q.CreatedAfter, q.CreatedBefore)
+
+	if s.DB.Dialector.Name() != "postgres" {
+		for _, t := range q.Terms {
+			for _, w := range t.Words {
+				// LOWER only folds ASCII on some databases, so other words
+				// are left to RankSearch.
+				if isASCII(w) {
+					query = query.Where("LOWER(body) LIKE ?", "%"+w+"%")
+				}
+			}
+		}
+
+		var comments []comment.Comment
+		if result := query.Find(&comments); result.Error != nil {
+			return nil, translate(result.Error)
+		}
+		return comment.RankSearch(comments, q), nil
+	}
+
+	tsq := tsquery(q.Terms)
+	results := []comment.SearchResult{}
+	result := query.
+		Select(
+			"comments.*, ts_rank_cd(search_vector, to_tsquery('english', ?)) AS rank, ts_headline('english', "+escapedBody+", to_tsquery('english', ?), ?) AS snippet",
+			tsq, tsq, headlineOptions,
+		).
+		Where("search_vector @@ to_tsquery('english', ?)", tsq).
+		Order("rank DESC, id DESC").
+		Offset(q.Offset).
+		Limit(q.Limit).
+		Find(&results)
+	if result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	return results, nil
+}
+
+// tsquery renders terms in to_tsquery syntax: words within a phrase are
+// joined with <->, terms with & and prefixes get :*. comment.ParseSearch
+// only produces letters and digits, so nothing needs quoting.
+func tsquery(terms []comment.Term) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		phrase := strings.Join(t.Words, " <-> ")
+		if t.Prefix {
+			phrase += ":*"
+		}
+		if len(t.Words) > 1 {
+			phrase = "(" + phrase + ")"
+		}
+		parts[i] = phrase
+	}
+	return strings.Join(parts, " & ")
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
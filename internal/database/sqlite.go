@@ -0,0 +1,15 @@
+//go:build sqlite
+
+package database
+
+import (
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// sqliteDialector opens the file-backed SQLite database at path using a pure
+// Go driver, so the binary can still be built with CGO_ENABLED=0.
+func sqliteDialector(path string) (gorm.Dialector, error) {
+	dsn := path + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+	return sqlite.Open(dsn), nil
+}
@@ -0,0 +1,15 @@
+//go:build !sqlite
+
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrSQLiteDisabled = errors.New("sqlite support is not compiled in, rebuild with -tags sqlite")
+
+func sqliteDialector(path string) (gorm.Dialector, error) {
+	return nil, ErrSQLiteDisabled
+}
@@ -0,0 +1,308 @@
+//go:build sqlite
+
+package database_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/config"
+	"github.com/dvl-mukesh/go-workshop/internal/database"
+	"gorm.io/gorm"
+)
+
+var ctx = context.Background()
+
+func newSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := database.NewDatabase(&config.Environment{
+		DbDriver: config.DriverSQLite,
+		DbPath:   filepath.Join(t.TempDir(), "comments.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDb, err := db.DB(); err == nil {
+			sqlDb.Close()
+		}
+	})
+	return db
+}
+
+func newStore(t *testing.T) *database.CommentStore {
+	t.Helper()
+
+	db := newSQLite(t)
+	if err := database.MigrateDB(db); err != nil {
+		t.Fatalf("MigrateDB: %v", err)
+	}
+	return database.NewCommentStore(db)
+}
+
+func TestSQLiteStore(t *testing.T) {
+	store := newStore(t)
+	s := comment.NewService(store, comment.WithPolicy(&comment.Policy{}))
+	admin := auth.WithPrincipal(ctx, auth.Principal{Subject: "root", Roles: []string{comment.RoleAdmin}})
+
+	root, err := s.PostComment(ctx, comment.Comment{Slug: "post", Author: "alice", Body: "hello world"})
+	if err != nil {
+		t.Fatalf("PostComment: %v", err)
+	}
+	reply, err := s.ReplyToComment(ctx, root.ID, comment.Comment{Author: "bob", Body: "a reply"})
+	if err != nil {
+		t.Fatalf("ReplyToComment: %v", err)
+	}
+	if reply.ParentID == nil || *reply.ParentID != root.ID || reply.Slug != "post" {
+		t.Errorf("reply = %+v, want a reply to %d on post", reply, root.ID)
+	}
+
+	root.Body = "hello there world"
+	updated, err := s.UpdateComment(admin, root.ID, root, "typo")
+	if err != nil {
+		t.Fatalf("UpdateComment: %v", err)
+	}
+	if !updated.Edited || updated.EditCount != 1 {
+		t.Errorf("updated = %+v, want edited once", updated)
+	}
+	revisions, err := s.GetRevisions(admin, root.ID)
+	if err != nil || len(revisions) != 2 || revisions[0].Body != "hello world" {
+		t.Errorf("GetRevisions = %+v, %v, want the original and the edit", revisions, err)
+	}
+
+	page, err := s.ListComments(ctx, comment.ListOptions{Slug: "post", Limit: 1})
+	if err != nil || len(page.Items) != 1 || page.Page.NextCursor == "" {
+		t.Fatalf("ListComments = %+v, %v, want one item and a cursor", page, err)
+	}
+	next, err := s.ListComments(ctx, comment.ListOptions{Slug: "post", Limit: 1, Cursor: page.Page.NextCursor})
+	if err != nil || len(next.Items) != 1 || next.Items[0].ID == page.Items[0].ID {
+		t.Errorf("second page = %+v, %v", next, err)
+	}
+
+	results, err := s.SearchComments(ctx, comment.SearchOptions{Query: "there"})
+	if err != nil || len(results.Items) != 1 || results.Items[0].ID != root.ID {
+		t.Errorf("SearchComments = %+v, %v, want the edited comment", results, err)
+	}
+
+	if _, err := s.Vote(ctx, reply.ID, 1); err != nil {
+		t.Fatalf("Vote: %v", err)
+	}
+
+	if err := s.DeleteComment(admin, reply.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	if _, err := s.GetComment(ctx, reply.ID); !errors.Is(err, comment.ErrCommentNotFound) {
+		t.Errorf("GetComment after delete: got %v, want ErrCommentNotFound", err)
+	}
+	if _, err := s.RestoreComment(admin, reply.ID); err != nil {
+		t.Fatalf("RestoreComment: %v", err)
+	}
+	if err := s.DeleteComment(admin, reply.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	if err := s.PurgeComment(admin, reply.ID); err != nil {
+		t.Fatalf("PurgeComment: %v", err)
+	}
+
+	audit, err := s.GetAuditLog(admin, comment.AuditOptions{CommentID: reply.ID})
+	if err != nil {
+		t.Fatalf("GetAuditLog: %v", err)
+	}
+	var actions []comment.AuditAction
+	for _, e := range audit.Items {
+		actions = append(actions, e.Action)
+	}
+	want := []comment.AuditAction{comment.AuditPurge, comment.AuditDelete, comment.AuditRestore, comment.AuditDelete, comment.AuditCreate}
+	if len(actions) != len(want) {
+		t.Fatalf("audit actions = %v, want %v", actions, want)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Errorf("audit actions = %v, want %v", actions, want)
+			break
+		}
+	}
+
+	now := time.Now()
+	events, err := store.ClaimEvents(ctx, now, now.Add(time.Minute), 100)
+	if err != nil {
+		t.Fatalf("ClaimEvents: %v", err)
+	}
+	// Create, reply, update, delete, restore, delete.
+	if len(events) != 6 {
+		t.Fatalf("claimed %d events, want 6", len(events))
+	}
+	if again, _ := store.ClaimEvents(ctx, now, now.Add(time.Minute), 100); len(again) != 0 {
+		t.Errorf("claimed %d leased events again", len(again))
+	}
+	if err := store.MarkDelivered(ctx, events[0].ID, now); err != nil {
+		t.Fatalf("MarkDelivered: %v", err)
+	}
+	if n, err := store.PruneDelivered(ctx, now.Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("PruneDelivered = %d, %v, want 1", n, err)
+	}
+}
+
+func TestSQLiteOutboxKeyOrder(t *testing.T) {
+	store := newStore(t)
+	s := comment.NewService(store)
+
+	a, err := s.PostComment(ctx, comment.Comment{Slug: "post", Body: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Body = "edited"
+	if _, err := s.UpdateComment(ctx, a.ID, a, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PostComment(ctx, comment.Comment{Slug: "post", Body: "second"}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	events, err := store.ClaimEvents(ctx, now, now.Add(time.Minute), 10)
+	if err != nil || len(events) != 3 {
+		t.Fatalf("ClaimEvents = %+v, %v, want 3 events", events, err)
+	}
+	if err := store.MarkFailed(ctx, events[0].ID, now.Add(10*time.Minute), "unavailable"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkDelivered(ctx, events[2].ID, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// The update's lease has run out, but the create before it is not due.
+	later := now.Add(2 * time.Minute)
+	if held, _ := store.ClaimEvents(ctx, later, later.Add(time.Minute), 10); len(held) != 0 {
+		t.Fatalf("claimed %+v ahead of the failed create", held)
+	}
+
+	if err := store.MarkDead(ctx, events[0].ID, later, "unavailable"); err != nil {
+		t.Fatal(err)
+	}
+	next, err := store.ClaimEvents(ctx, later, later.Add(time.Minute), 10)
+	if err != nil || len(next) != 1 || next[0].ID != events[1].ID {
+		t.Fatalf("ClaimEvents after giving up = %+v, %v, want the update", next, err)
+	}
+	if n, err := store.PruneDelivered(ctx, later.Add(time.Second)); err != nil || n != 2 {
+		t.Errorf("PruneDelivered = %d, %v, want the dead and the delivered event", n, err)
+	}
+}
+
+func TestSQLiteAuditFailureRollsBack(t *testing.T) {
+	store := newStore(t)
+	s := comment.NewService(store)
+
+	c, err := s.PostComment(ctx, comment.Comment{Slug: "post", Body: "kept"})
+	if err != nil {
+		t.Fatalf("PostComment: %v", err)
+	}
+	if err := store.DB.Exec("DROP TABLE audit_log").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.PostComment(ctx, comment.Comment{Slug: "post", Body: "lost"}); err == nil {
+		t.Error("PostComment succeeded without an audit log")
+	}
+	c.Body = "edited"
+	if _, err := s.UpdateComment(ctx, c.ID, c, ""); err == nil {
+		t.Error("UpdateComment succeeded without an audit log")
+	}
+
+	comments, err := store.GetAllComments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0].Body != "kept" || comments[0].Edited {
+		t.Errorf("comments = %+v, want only the first, unedited", comments)
+	}
+	revisions, _ := store.ListRevisions(ctx, c.ID)
+	if len(revisions) != 0 {
+		t.Errorf("kept %d revisions of the failed edit", len(revisions))
+	}
+}
+
+func TestSQLiteEditHeldForReview(t *testing.T) {
+	store := newStore(t)
+	s := comment.NewService(store, comment.WithPolicy(&comment.Policy{}), comment.WithDefaultStatus(comment.StatusPending))
+	alice := auth.WithPrincipal(ctx, auth.Principal{Subject: "alice"})
+	mod := auth.WithPrincipal(ctx, auth.Principal{Subject: "mo", Roles: []string{comment.RoleModerator}})
+
+	c, err := s.PostComment(alice, comment.Comment{Slug: "post", Body: "clean"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ModerateComment(mod, c.ID, comment.StatusApproved, ""); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	events, err := store.ClaimEvents(ctx, now, now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		store.MarkDelivered(ctx, e.ID, now)
+	}
+
+	c.Body = "edited"
+	updated, err := s.UpdateComment(alice, c.ID, c, "")
+	if err != nil {
+		t.Fatalf("UpdateComment: %v", err)
+	}
+	stored, _ := store.GetComment(ctx, c.ID)
+	for _, got := range []comment.Comment{updated, stored} {
+		if got.Status != comment.StatusPending || got.ModerationReason != "edited" || got.Body != "edited" || got.EditCount != 1 {
+			t.Errorf("edited comment = %+v, want it held for review", got)
+		}
+	}
+
+	// The edit and the status change are one write: one entry, one event.
+	entries, err := store.ListAudit(ctx, comment.AuditQuery{CommentID: c.ID, Desc: true, Limit: 10})
+	if err != nil || len(entries) != 3 || entries[0].Action != comment.AuditUpdate || entries[0].After.Status != comment.StatusPending {
+		t.Errorf("audit log = %+v, %v, want create, moderate and a single update", entries, err)
+	}
+	events, err = store.ClaimEvents(ctx, time.Now(), time.Now().Add(time.Minute), 10)
+	if err != nil || len(events) != 1 {
+		t.Errorf("ClaimEvents = %+v, %v, want one event for the edit", events, err)
+	}
+}
+
+func TestSQLiteRedactsAuditBodies(t *testing.T) {
+	db := newSQLite(t)
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.To(10); err != nil {
+		t.Fatalf("To(10): %v", err)
+	}
+	err = db.Exec(`INSERT INTO audit_log (action, comment_id, before, after) VALUES ('update', 1, ?, ?)`,
+		`{"id":1,"body":"secret","slug":"post"}`, `{"id":1,"body":"also secret","slug":"post"}`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	entries, err := database.NewCommentStore(db).ListAudit(ctx, comment.AuditQuery{Limit: 10})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("ListAudit = %+v, %v", entries, err)
+	}
+	if e := entries[0]; e.Before.Body != "" || e.After.Body != "" || e.Before.Slug != "post" {
+		t.Errorf("entry after redaction = %+v / %+v, want bodies blanked and the rest kept", e.Before, e.After)
+	}
+	if err := db.Exec("UPDATE audit_log SET actor = 'mallory'").Error; err == nil {
+		t.Error("audit_log accepted an update after the redaction")
+	}
+
+	if err := m.Down(); err != nil {
+		t.Errorf("Down: %v", err)
+	}
+}
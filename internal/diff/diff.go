@@ -0,0 +1,126 @@
+// Package diff computes word-level differences between two texts using
+// Myers' algorithm.
+package diff
+
+import "regexp"
+
+// Op is what an Edit does to the old text.
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// Edit is a run of text that is unchanged, inserted or deleted.
+type Edit struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// MaxEdits bounds the work Words does. Texts further apart than this are
+// reported as deleted and inserted in full.
+const MaxEdits = 1000
+
+var tokenPattern = regexp.MustCompile(`\s+|[^\s]+`)
+
+// Words returns the edits that turn a into b, splitting both into words and
+// runs of whitespace. Joining the Text of the equal and delete edits gives a
+// back; equal and insert give b.
+func Words(a, b string) []Edit {
+	return merge(diff(tokenPattern.FindAllString(a, -1), tokenPattern.FindAllString(b, -1)))
+}
+
+// diff is Myers' O(ND) algorithm, keeping only the diagonals each step can
+// reach so memory grows with the square of the edit distance.
+func diff(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	limit := min(n+m, MaxEdits)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+
+	edits := make([]Edit, 0, n+m)
+	for _, s := range a {
+		edits = append(edits, Edit{OpDelete, s})
+	}
+	for _, s := range b {
+		edits = append(edits, Edit{OpInsert, s})
+	}
+	return edits
+}
+
+func backtrack(a, b []string, trace [][]int) []Edit {
+	var edits []Edit
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d] holds diagonals -d-1..d+1 as they were before step d.
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d+1] }
+
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, Edit{OpEqual, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, Edit{OpInsert, b[y-1]})
+			} else {
+				edits = append(edits, Edit{OpDelete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// merge joins adjacent edits with the same op.
+func merge(edits []Edit) []Edit {
+	merged := []Edit{}
+	for _, e := range edits {
+		if last := len(merged) - 1; last >= 0 && merged[last].Op == e.Op {
+			merged[last].Text += e.Text
+			continue
+		}
+		merged = append(merged, e)
+	}
+	return merged
+}
@@ -0,0 +1,69 @@
+package diff_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/dvl-mukesh/go-workshop/internal/diff"
+)
+
+// apply rebuilds the old and new texts from edits.
+func apply(edits []diff.Edit) (string, string) {
+	var a, b strings.Builder
+	for _, e := range edits {
+		if e.Op != diff.OpInsert {
+			a.WriteString(e.Text)
+		}
+		if e.Op != diff.OpDelete {
+			b.WriteString(e.Text)
+		}
+	}
+	return a.String(), b.String()
+}
+
+func TestWords(t *testing.T) {
+	got := diff.Words("the quick brown fox", "the slow brown  fox jumps")
+	want := []diff.Edit{
+		{diff.OpEqual, "the "},
+		{diff.OpDelete, "quick"},
+		{diff.OpInsert, "slow"},
+		{diff.OpEqual, " brown"},
+		{diff.OpDelete, " "},
+		{diff.OpInsert, "  "},
+		{diff.OpEqual, "fox"},
+		{diff.OpInsert, " jumps"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("edit %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if edits := diff.Words("", ""); len(edits) != 0 {
+		t.Errorf("diff of empty texts = %+v", edits)
+	}
+}
+
+func TestWordsRoundTrip(t *testing.T) {
+	words := []string{"a", "b", "c", " ", "\n"}
+	r := rand.New(rand.NewSource(1))
+	text := func() string {
+		var b strings.Builder
+		for i := r.Intn(40); i > 0; i-- {
+			b.WriteString(words[r.Intn(len(words))])
+		}
+		return b.String()
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := text(), text()
+		gotA, gotB := apply(diff.Words(a, b))
+		if gotA != a || gotB != b {
+			t.Fatalf("Words(%q, %q) rebuilds %q, %q", a, b, gotA, gotB)
+		}
+	}
+}
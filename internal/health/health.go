@@ -0,0 +1,149 @@
+// Package health keeps a registry of dependency checks used by the liveness,
+// readiness and detailed health endpoints.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	DefaultTimeout = 2 * time.Second
+)
+
+// Checker reports on a single dependency. A non-nil error marks it as down;
+// details are included in the detailed health report either way.
+type Checker interface {
+	Check(ctx context.Context) (map[string]any, error)
+}
+
+// CheckerFunc adapts a function to the Checker interface.
+type CheckerFunc func(ctx context.Context) (map[string]any, error)
+
+func (f CheckerFunc) Check(ctx context.Context) (map[string]any, error) {
+	return f(ctx)
+}
+
+type Result struct {
+	Status    string         `json:"status"`
+	LatencyMS float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Public returns the report without the raw error of each failing check,
+// which can carry hosts or credentials; statuses, latencies and details are
+// kept.
+func (r Report) Public() Report {
+	public := Report{Status: r.Status}
+	if r.Checks != nil {
+		public.Checks = make(map[string]Result, len(r.Checks))
+		for name, result := range r.Checks {
+			result.Error = ""
+			public.Checks[name] = result
+		}
+	}
+	return public
+}
+
+type check struct {
+	name    string
+	checker Checker
+}
+
+// Registry holds the registered checks and whether the server is accepting
+// traffic.
+type Registry struct {
+	Timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+	ready  atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		Timeout: DefaultTimeout,
+	}
+}
+
+// Register adds a named check. Registering the same name again replaces it.
+func (r *Registry) Register(name string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, c := range r.checks {
+		if c.name == name {
+			r.checks[i].checker = checker
+			return
+		}
+	}
+	r.checks = append(r.checks, check{name: name, checker: checker})
+}
+
+func (r *Registry) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+func (r *Registry) Ready() bool {
+	return r.ready.Load()
+}
+
+// Run executes every check concurrently, each bounded by the registry
+// timeout, and reports down if any of them fails.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c.checker)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]Result, len(checks)),
+	}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, checker Checker) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := checker.Check(ctx)
+	result := Result{
+		Status:    StatusUp,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
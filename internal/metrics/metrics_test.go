@@ -0,0 +1,76 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dvl-mukesh/go-workshop/internal/metrics"
+)
+
+func TestExposition(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	requests := reg.NewCounterVec("requests_total", "Total requests.", "route")
+	requests.Inc("/a")
+	requests.Add(2, `/b"\`)
+
+	latency := reg.NewHistogramVec("latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(5, "/a")
+
+	reg.NewGaugeFunc("open_connections", "Open connections.", func() float64 { return 3 })
+
+	w := httptest.NewRecorder()
+	reg.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if got := w.Header().Get("Content-Type"); got != metrics.ContentType {
+		t.Errorf("unexpected content type %q", got)
+	}
+
+	body, _ := io.ReadAll(w.Body)
+	want := `# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{route="/a"} 1
+requests_total{route="/b\"\\"} 2
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 1
+latency_seconds_bucket{route="/a",le="1"} 2
+latency_seconds_bucket{route="/a",le="+Inf"} 3
+latency_seconds_sum{route="/a"} 5.55
+latency_seconds_count{route="/a"} 3
+# HELP open_connections Open connections.
+# TYPE open_connections gauge
+open_connections 3
+`
+	if string(body) != want {
+		t.Errorf("unexpected exposition:\n%s\nwant:\n%s", body, want)
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	c := reg.NewCounterVec("c_total", "C.", "a", "b")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	c.Inc("only-one")
+}
+
+func TestDuplicateNamePanics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	reg.NewCounterVec("dup_total", "Dup.")
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "dup_total") {
+			t.Errorf("expected duplicate panic, got %v", r)
+		}
+	}()
+	reg.NewCounterVec("dup_total", "Dup.")
+}
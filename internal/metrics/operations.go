@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// Operations counts the calls and errors of a service's operations and
+// records their latency, labeled by operation name.
+type Operations struct {
+	calls    *CounterVec
+	errors   *CounterVec
+	duration *HistogramVec
+}
+
+// NewOperations registers the operation metrics under the given prefix, for
+// example "comment_service".
+func NewOperations(r *Registry, prefix string) *Operations {
+	return &Operations{
+		calls: r.NewCounterVec(prefix+"_operations_total",
+			"Total number of service operations.", "operation"),
+		errors: r.NewCounterVec(prefix+"_operation_errors_total",
+			"Total number of service operations that returned an error.", "operation"),
+		duration: r.NewHistogramVec(prefix+"_operation_duration_seconds",
+			"Service operation latency in seconds.", DefBuckets, "operation"),
+	}
+}
+
+// Hook records one operation. It matches comment.Hook.
+func (o *Operations) Hook(ctx context.Context, op string) (context.Context, func(error)) {
+	start := time.Now()
+	return ctx, func(err error) {
+		o.calls.Inc(op)
+		if err != nil {
+			o.errors.Inc(op)
+		}
+		o.duration.Observe(Since(start), op)
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+)
+
+var MsgUnauthorized = "Unauthorized"
+
+// Authenticate verifies the bearer token or API key on each request and
+// stores the principal in the request context. Invalid credentials are always
+// rejected; requests without credentials may only read.
+func Authenticate(a *auth.Authenticator) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, present, err := a.Authenticate(r)
+
+			if err != nil || (!present && !isReadOnly(r.Method)) {
+				if err != nil {
+					slog.WarnContext(r.Context(), "authentication failed", "err", err)
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer realm="comments-api"`)
+				dvlutil.WriteJSON(w, http.StatusUnauthorized, dvlutil.Response{
+					Status: dvlutil.StatusCodeNotOK,
+					Msg:    MsgUnauthorized,
+				})
+				return
+			}
+
+			if present {
+				setAccessUser(r.Context(), principal.Subject)
+				r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isReadOnly(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dvl-mukesh/go-workshop/internal/request"
+)
+
+// ClientIP records the client address, honoring forwarding headers from
+// trusted proxies, in the request context for later middleware and the
+// service layer.
+func ClientIP(trusted request.TrustedProxies) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := request.WithClientIP(r.Context(), trusted.ClientIP(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -1,15 +1,82 @@
 package middleware
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/request"
 )
 
+// accessEntry collects details for the access log that are only known to
+// middleware further down the stack, such as the authenticated user.
+type accessEntry struct {
+	user string
+}
+
+type accessEntryKey struct{}
+
+func setAccessUser(ctx context.Context, user string) {
+	if e, ok := ctx.Value(accessEntryKey{}).(*accessEntry); ok {
+		e.user = user
+	}
+}
+
+// statusRecorder remembers the status code and body size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusRecorder) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Logging writes an access log line for every request once it completes.
 func Logging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		next.ServeHTTP(w, r)
-		log.Println(r.Method, r.URL.Path, time.Since(start))
+		entry := &accessEntry{}
+		rec := &statusRecorder{ResponseWriter: w}
+
+		ctx := context.WithValue(r.Context(), accessEntryKey{}, entry)
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		slog.Default().LogAttrs(ctx, level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int("bytes", rec.bytes),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", request.ClientIPFromContext(ctx)),
+			slog.String("user", entry.user),
+		)
 	})
 }
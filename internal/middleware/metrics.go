@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/metrics"
+)
+
+// RouteUnmatched labels requests that no route matched, so unknown paths
+// can't blow up the number of series.
+const RouteUnmatched = "unmatched"
+
+// MethodOther labels requests with a method outside the standard ones, for
+// the same reason.
+const MethodOther = "other"
+
+// Metrics counts requests and records their latency, labeled by method,
+// route pattern and status. route maps a request to the pattern it is
+// served by, or "" when none matches.
+func Metrics(reg *metrics.Registry, route func(*http.Request) string) Middleware {
+	requests := reg.NewCounterVec("http_requests_total",
+		"Total number of HTTP requests.", "method", "route", "status")
+	duration := reg.NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency in seconds.", metrics.DefBuckets, "method", "route", "status")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			pattern := route(r)
+			if pattern == "" {
+				pattern = RouteUnmatched
+			}
+
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			status := strconv.Itoa(rec.status)
+			method := methodLabel(r.Method)
+
+			requests.Inc(method, pattern, status)
+			duration.Observe(metrics.Since(start), method, pattern, status)
+		})
+	}
+}
+
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return MethodOther
+}
@@ -0,0 +1,48 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dvl-mukesh/go-workshop/internal/metrics"
+	"github.com/dvl-mukesh/go-workshop/internal/middleware"
+)
+
+func TestMetricsLabels(t *testing.T) {
+	reg := metrics.NewRegistry()
+	route := func(r *http.Request) string {
+		if r.URL.Path == "/api/comment" {
+			return "/api/comment"
+		}
+		return ""
+	}
+	handler := middleware.Metrics(reg, route)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/api/comment"},
+		{"BREW", "/api/comment"},
+		{"X-RANDOM-1", "/api/comment"},
+		{http.MethodGet, "/nope"},
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	reg.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/api/comment",status="200"} 1`,
+		`http_requests_total{method="other",route="/api/comment",status="200"} 2`,
+		`http_requests_total{method="GET",route="unmatched",status="200"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s in:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "BREW") || strings.Contains(body, "X-RANDOM") {
+		t.Errorf("metrics label a non-standard method:\n%s", body)
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+	"github.com/dvl-mukesh/go-workshop/internal/ratelimit"
+	"github.com/dvl-mukesh/go-workshop/internal/request"
+)
+
+var MsgTooManyRequests = "Too Many Requests"
+
+// RateLimit throttles each client separately for reads and writes. Clients
+// are identified by API key, then authenticated user, then IP address, so it
+// must run after Authenticate and ClientIP. If the store fails the request is
+// let through rather than taking the API down with it.
+func RateLimit(store ratelimit.Store, read, write ratelimit.Limit) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, class := write, "write"
+			if isReadOnly(r.Method) {
+				limit, class = read, "read"
+			}
+
+			if allow(w, r, store, class+":"+clientKey(r), limit) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RateLimitIP throttles all requests from each IP address together. It
+// must run after ClientIP and before Authenticate, so that requests with
+// invalid credentials are counted too and credentials cannot be guessed
+// faster than limit allows.
+func RateLimitIP(store ratelimit.Store, limit ratelimit.Limit) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allow(w, r, store, "all:"+clientIP(r), limit) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// allow counts the request against key and reports whether it may go on,
+// writing the rate limit headers and, once the limit is reached, a 429.
+func allow(w http.ResponseWriter, r *http.Request, store ratelimit.Store, key string, limit ratelimit.Limit) bool {
+	res, err := store.Allow(r.Context(), key, limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "rate limit store failed, allowing request", "err", err)
+		return true
+	}
+
+	h := w.Header()
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Window.Seconds())))
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+
+	if !res.Allowed {
+		h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+		dvlutil.WriteJSON(w, http.StatusTooManyRequests, dvlutil.Response{
+			Status: dvlutil.StatusCodeNotOK,
+			Msg:    MsgTooManyRequests,
+		})
+		return false
+	}
+	return true
+}
+
+func clientKey(r *http.Request) string {
+	if p, ok := auth.PrincipalFromContext(r.Context()); ok {
+		if p.Method == auth.MethodAPIKey {
+			return "key:" + p.Subject
+		}
+		return "user:" + p.Subject
+	}
+	return clientIP(r)
+}
+
+func clientIP(r *http.Request) string {
+	if ip := request.ClientIPFromContext(r.Context()); ip != "" {
+		return "ip:" + ip
+	}
+	return "ip:" + r.RemoteAddr
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,96 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+	"github.com/dvl-mukesh/go-workshop/internal/config"
+	"github.com/dvl-mukesh/go-workshop/internal/database/memory"
+	"github.com/dvl-mukesh/go-workshop/internal/middleware"
+	"github.com/dvl-mukesh/go-workshop/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	read := ratelimit.Limit{Requests: 2, Window: time.Minute}
+	write := ratelimit.Limit{Requests: 1, Window: time.Minute}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	stack := middleware.CreateStack(
+		middleware.ClientIP(nil),
+		middleware.RateLimit(memory.NewRateLimitStore(), read, write),
+	)
+	handler := stack(ok)
+
+	do := func(method, ip string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/comment", nil)
+		r.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := do(http.MethodGet, "10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("read %d: got %d", i, w.Code)
+		}
+	}
+
+	w := do(http.MethodGet, "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("expected RateLimit-Remaining 0, got %q", got)
+	}
+
+	// Writes have their own budget and other clients are unaffected.
+	if w := do(http.MethodPost, "10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("write: got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("other client: got %d", w.Code)
+	}
+}
+
+func TestRateLimitIPThrottlesBadCredentials(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(&config.Environment{AuthAPIKeys: "secret=alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stack := middleware.CreateStack(
+		middleware.ClientIP(nil),
+		middleware.RateLimitIP(memory.NewRateLimitStore(), ratelimit.Limit{Requests: 3, Window: time.Minute}),
+		middleware.Authenticate(authenticator),
+	)
+	handler := stack(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(key, ip string) int {
+		r := httptest.NewRequest(http.MethodPost, "/api/comment", nil)
+		r.RemoteAddr = ip + ":1234"
+		r.Header.Set(auth.APIKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := do("guess", "10.0.0.1"); code != http.StatusUnauthorized {
+			t.Fatalf("guess %d: got %d, want 401", i, code)
+		}
+	}
+	if code := do("guess", "10.0.0.1"); code != http.StatusTooManyRequests {
+		t.Errorf("guess after the limit: got %d, want 429", code)
+	}
+	if code := do("secret", "10.0.0.1"); code != http.StatusTooManyRequests {
+		t.Errorf("valid key from a throttled IP: got %d, want 429", code)
+	}
+	if code := do("secret", "10.0.0.2"); code != http.StatusOK {
+		t.Errorf("other IP: got %d, want 200", code)
+	}
+}
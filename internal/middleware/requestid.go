@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dvl-mukesh/go-workshop/internal/request"
+)
+
+// RequestID tags each request with an ID, adopting the caller's X-Request-ID
+// when it is well formed and generating one otherwise. The ID is echoed in
+// the response and stored in the request context so every log line written
+// while serving the request can be correlated.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(request.IDHeader)
+		if !request.ValidID(id) {
+			id = request.NewID()
+		}
+
+		w.Header().Set(request.IDHeader, id)
+		next.ServeHTTP(w, r.WithContext(request.WithID(r.Context(), id)))
+	})
+}
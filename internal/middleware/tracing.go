@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dvl-mukesh/go-workshop/internal/request"
+	"github.com/dvl-mukesh/go-workshop/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span for each request, continuing the caller's
+// trace when a valid traceparent header is present. Spans are named after
+// the route pattern rather than the raw path.
+func Tracing(tracer *tracing.Tracer, route func(*http.Request) string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := tracing.Extract(r.Context(), r.Header)
+
+			pattern := route(r)
+			if pattern == "" {
+				pattern = RouteUnmatched
+			}
+
+			ctx, span := tracer.Start(ctx, r.Method+" "+pattern,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("http.route", pattern),
+					attribute.String("url.path", r.URL.Path),
+					attribute.String("client.address", request.ClientIPFromContext(ctx)),
+					attribute.String("request.id", request.IDFromContext(ctx)),
+				))
+			defer span.End()
+
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			span.SetAttributes(
+				attribute.Int("http.response.status_code", rec.status),
+				attribute.Int("http.response.body.size", rec.bytes),
+			)
+			if rec.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rec.status))
+			}
+		})
+	}
+}
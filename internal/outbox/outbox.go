@@ -0,0 +1,270 @@
+// Package outbox relays domain events recorded alongside the changes they
+// describe to external sinks. Events are delivered at least once: a sink may
+// see an event again after a failure or restart, so consumers should
+// deduplicate by event ID. Events with the same key are delivered in the
+// order they were recorded.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	DefaultInterval   = 5 * time.Second
+	DefaultBatchSize  = 100
+	DefaultLease      = time.Minute
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = 10 * time.Minute
+	DefaultRetain     = 24 * time.Hour
+	// DefaultMaxAttempts gives a failing event most of a day of retries at
+	// DefaultMaxBackoff.
+	DefaultMaxAttempts = 150
+
+	// pruneInterval is how often Run deletes delivered and dead events.
+	pruneInterval = 10 * time.Minute
+)
+
+// Event is a domain event waiting in, or delivered from, the outbox. Only
+// the exported JSON fields are sent to sinks.
+type Event struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Type string `json:"type"`
+	// Key identifies what the event is about, such as a comment ID.
+	Key       string          `json:"key"`
+	Payload   json.RawMessage `json:"payload" gorm:"serializer:json"`
+	CreatedAt time.Time       `json:"created_at"`
+
+	Attempts      int        `json:"-"`
+	NextAttemptAt time.Time  `json:"-" gorm:"index"`
+	DeliveredAt   *time.Time `json:"-" gorm:"index"`
+	// DeadAt is when the relay gave up on the event after MaxAttempts.
+	DeadAt    *time.Time `json:"-" gorm:"index"`
+	LastError string     `json:"-"`
+}
+
+func (Event) TableName() string {
+	return "outbox_events"
+}
+
+// New builds an event of the given type with payload encoded as JSON, due
+// for delivery straight away.
+func New(eventType, key string, payload any) (Event, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	now := time.Now()
+	return Event{
+		Type:          eventType,
+		Key:           key,
+		Payload:       b,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// Store is the outbox as seen by the Relay. Events are added by whatever
+// records the change they describe, in the same transaction.
+type Store interface {
+	// ClaimEvents returns up to limit pending events due at now, oldest
+	// first, and defers their next attempt to leaseUntil so other relays
+	// skip them while they are being delivered. An event is pending until it
+	// is delivered or dead. Events are held back while an earlier pending
+	// event with the same key is not due, so that keys stay in order, and
+	// concurrent claims never return events with the same key.
+	ClaimEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]Event, error)
+	MarkDelivered(ctx context.Context, ID uint, at time.Time) error
+	// MarkFailed records a failed attempt and when to try again.
+	MarkFailed(ctx context.Context, ID uint, next time.Time, lastErr string) error
+	// MarkDead records a failed attempt after which the event is given up on.
+	MarkDead(ctx context.Context, ID uint, at time.Time, lastErr string) error
+	// PruneDelivered deletes events delivered or given up on before the
+	// given time.
+	PruneDelivered(ctx context.Context, before time.Time) (int64, error)
+	// PruneUndelivered deletes pending events recorded before the given
+	// time.
+	PruneUndelivered(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Sink is somewhere events are delivered, such as a webhook. Deliver returns
+// an error if the event should be retried.
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, e Event) error
+}
+
+// Relay polls the outbox and delivers due events to every sink. An event
+// counts as delivered once all sinks have accepted it; if any fails it is
+// retried for all of them after an exponential backoff, up to MaxAttempts
+// times. Later events with the same key wait until it is delivered or given
+// up on.
+//
+// A relay without sinks delivers nothing. It only prunes, dropping pending
+// events once they are older than Retain, so that a sink configured later
+// still receives recent changes.
+type Relay struct {
+	Store Store
+	Sinks []Sink
+	// Interval is how often the outbox is polled when it is drained.
+	Interval  time.Duration
+	BatchSize int
+	// Lease is how long a claimed event is held before another attempt may
+	// claim it, for example after a crash mid-delivery.
+	Lease      time.Duration
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxAttempts is how many times an event is tried before it is given up
+	// on and left for pruning.
+	MaxAttempts int
+	// Retain is how long delivered and dead events, or every event when
+	// there are no sinks, are kept before being pruned.
+	Retain time.Duration
+	Now    func() time.Time
+}
+
+func (r *Relay) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
+
+// backoff is the delay before attempt n+1 after n failures: MinBackoff
+// doubling up to MaxBackoff, with jitter so failing events spread out.
+func (r *Relay) backoff(n int) time.Duration {
+	minBackoff := orDefault(r.MinBackoff, DefaultMinBackoff)
+	maxBackoff := orDefault(r.MaxBackoff, DefaultMaxBackoff)
+
+	d := minBackoff
+	for i := 1; i < n && d < maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, maxBackoff)
+	return d/2 + rand.N(d/2+1)
+}
+
+// RunOnce claims and delivers one batch of due events, returning how many
+// were claimed.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	if len(r.Sinks) == 0 {
+		return 0, nil
+	}
+	batch := r.BatchSize
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	now := r.now()
+
+	events, err := r.Store.ClaimEvents(ctx, now, now.Add(orDefault(r.Lease, DefaultLease)), batch)
+	if err != nil {
+		return 0, err
+	}
+
+	maxAttempts := r.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	// Keys whose earlier event failed in this batch. Their later events stay
+	// leased, and once the lease ends the store holds them back until the
+	// failed event is delivered or dead.
+	failed := make(map[string]bool)
+	for _, e := range events {
+		if failed[e.Key] {
+			continue
+		}
+		if err := r.deliver(ctx, e); err != nil {
+			attempts := e.Attempts + 1
+			if attempts >= maxAttempts {
+				slog.ErrorContext(ctx, "giving up on outbox event", "id", e.ID, "type", e.Type, "key", e.Key, "attempts", attempts, "err", err)
+				if err := r.Store.MarkDead(ctx, e.ID, r.now(), err.Error()); err != nil {
+					return len(events), err
+				}
+				continue
+			}
+			failed[e.Key] = true
+			next := r.now().Add(r.backoff(attempts))
+			slog.WarnContext(ctx, "delivering outbox event", "id", e.ID, "type", e.Type, "attempts", attempts, "retry_at", next, "err", err)
+			if err := r.Store.MarkFailed(ctx, e.ID, next, err.Error()); err != nil {
+				return len(events), err
+			}
+			continue
+		}
+		if err := r.Store.MarkDelivered(ctx, e.ID, r.now()); err != nil {
+			return len(events), err
+		}
+	}
+	return len(events), nil
+}
+
+func (r *Relay) deliver(ctx context.Context, e Event) error {
+	var errs []error
+	for _, sink := range r.Sinks {
+		if err := sink.Deliver(ctx, e); err != nil {
+			errs = append(errs, errors.New(sink.Name()+": "+err.Error()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run relays events until ctx is done. Full batches are followed straight
+// away by the next one; otherwise it waits Interval before polling again.
+func (r *Relay) Run(ctx context.Context) {
+	batch := r.BatchSize
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	ticker := time.NewTicker(orDefault(r.Interval, DefaultInterval))
+	defer ticker.Stop()
+
+	var pruned time.Time
+	for {
+		n, err := r.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "relaying outbox events", "err", err)
+		}
+		if err == nil && n == batch {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		if r.now().Sub(pruned) >= pruneInterval {
+			r.prune(ctx)
+			pruned = r.now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) prune(ctx context.Context) {
+	before := r.now().Add(-orDefault(r.Retain, DefaultRetain))
+	n, err := r.Store.PruneDelivered(ctx, before)
+	if err == nil && len(r.Sinks) == 0 {
+		var undelivered int64
+		undelivered, err = r.Store.PruneUndelivered(ctx, before)
+		n += undelivered
+	}
+	if err != nil && ctx.Err() == nil {
+		slog.ErrorContext(ctx, "pruning outbox events", "err", err)
+	} else if n > 0 {
+		slog.DebugContext(ctx, "pruned outbox events", "pruned", n)
+	}
+}
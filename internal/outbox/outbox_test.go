@@ -0,0 +1,267 @@
+package outbox_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/database/memory"
+	"github.com/dvl-mukesh/go-workshop/internal/outbox"
+)
+
+var ctx = context.Background()
+
+// recordingSink remembers the events it was given and fails while err is
+// set, or while fail returns true for the event.
+type recordingSink struct {
+	events []outbox.Event
+	err    error
+	fail   func(outbox.Event) bool
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Deliver(ctx context.Context, e outbox.Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.fail != nil && s.fail(e) {
+		return errors.New("rejected")
+	}
+	s.events = append(s.events, e)
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	store := memory.NewCommentStore()
+	svc := comment.NewService(store)
+
+	c, err := svc.PostComment(ctx, comment.Comment{Slug: "post", Author: "alice", Body: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Body = "edited"
+	if _, err := svc.UpdateComment(ctx, c.ID, c, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteComment(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	ok := &recordingSink{}
+	failing := &recordingSink{err: errors.New("unavailable")}
+	relay := &outbox.Relay{
+		Store:      store,
+		Sinks:      []outbox.Sink{ok, failing},
+		MinBackoff: time.Minute,
+		Now:        func() time.Time { return now },
+	}
+
+	// One sink failing holds the first event back for a retry, and the
+	// later ones for the same comment with it.
+	if n, err := relay.RunOnce(ctx); err != nil || n != 3 {
+		t.Fatalf("RunOnce = %d, %v, want 3 events", n, err)
+	}
+	if n, _ := relay.RunOnce(ctx); n != 0 {
+		t.Fatalf("retried %d events before the backoff", n)
+	}
+
+	failing.err = nil
+	now = now.Add(time.Minute)
+	if n, err := relay.RunOnce(ctx); err != nil || n != 3 {
+		t.Fatalf("RunOnce after backoff = %d, %v, want 3 events", n, err)
+	}
+	want := []string{comment.EventCommentCreated, comment.EventCommentUpdated, comment.EventCommentDeleted}
+	for i, e := range failing.events {
+		if e.Type != want[i] || e.Key != "1" {
+			t.Errorf("event %d = %s %s, want %s 1", i, e.Type, e.Key, want[i])
+		}
+	}
+	if len(failing.events) != 3 || len(ok.events) != 4 {
+		t.Errorf("delivered %d and %d events, want 3 and 4 (at least once)", len(failing.events), len(ok.events))
+	}
+
+	var updated comment.Comment
+	if err := json.Unmarshal(failing.events[1].Payload, &updated); err != nil || updated.Body != "edited" {
+		t.Errorf("updated payload = %+v, %v, want the edited comment", updated, err)
+	}
+
+	if n, _ := relay.RunOnce(ctx); n != 0 {
+		t.Errorf("redelivered %d events", n)
+	}
+	if n, err := store.PruneDelivered(ctx, now.Add(time.Second)); err != nil || n != 3 {
+		t.Errorf("PruneDelivered = %d, %v, want 3", n, err)
+	}
+}
+
+func TestRelayKeepsKeyOrder(t *testing.T) {
+	store := memory.NewCommentStore()
+	svc := comment.NewService(store)
+
+	a, err := svc.PostComment(ctx, comment.Comment{Slug: "post", Body: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Body = "edited"
+	if _, err := svc.UpdateComment(ctx, a.ID, a, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.PostComment(ctx, comment.Comment{Slug: "post", Body: "second"}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	broken := true
+	sink := &recordingSink{fail: func(e outbox.Event) bool { return broken && e.Key == "1" }}
+	relay := &outbox.Relay{
+		Store:      store,
+		Sinks:      []outbox.Sink{sink},
+		Lease:      time.Minute,
+		MinBackoff: 10 * time.Minute,
+		Now:        func() time.Time { return now },
+	}
+
+	if n, err := relay.RunOnce(ctx); err != nil || n != 3 {
+		t.Fatalf("RunOnce = %d, %v, want 3 events", n, err)
+	}
+	if len(sink.events) != 1 || sink.events[0].Key != "2" {
+		t.Fatalf("delivered %+v, want only the other comment's event", sink.events)
+	}
+
+	// The update's lease has run out, but the failed create is still
+	// waiting for its retry.
+	now = now.Add(2 * time.Minute)
+	if n, _ := relay.RunOnce(ctx); n != 0 {
+		t.Fatalf("claimed %d events ahead of the failed one", n)
+	}
+
+	broken = false
+	now = now.Add(10 * time.Minute)
+	if n, err := relay.RunOnce(ctx); err != nil || n != 2 {
+		t.Fatalf("RunOnce after backoff = %d, %v, want 2 events", n, err)
+	}
+	if len(sink.events) != 3 || sink.events[1].Type != comment.EventCommentCreated || sink.events[2].Type != comment.EventCommentUpdated {
+		t.Errorf("delivered %+v, want the create before the update", sink.events)
+	}
+}
+
+func TestRelayGivesUp(t *testing.T) {
+	store := memory.NewCommentStore()
+	svc := comment.NewService(store)
+
+	c, err := svc.PostComment(ctx, comment.Comment{Slug: "post", Body: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Body = "edited"
+	if _, err := svc.UpdateComment(ctx, c.ID, c, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	sink := &recordingSink{fail: func(e outbox.Event) bool { return e.Type == comment.EventCommentCreated }}
+	relay := &outbox.Relay{
+		Store:       store,
+		Sinks:       []outbox.Sink{sink},
+		MinBackoff:  time.Minute,
+		MaxAttempts: 2,
+		Now:         func() time.Time { return now },
+	}
+
+	if _, err := relay.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	// The second failure is the last: the create is dropped and the update
+	// behind it goes out.
+	if n, err := relay.RunOnce(ctx); err != nil || n != 2 {
+		t.Fatalf("RunOnce = %d, %v, want 2 events", n, err)
+	}
+	if len(sink.events) != 1 || sink.events[0].Type != comment.EventCommentUpdated {
+		t.Errorf("delivered %+v, want only the update", sink.events)
+	}
+
+	now = now.Add(time.Hour)
+	if n, _ := relay.RunOnce(ctx); n != 0 {
+		t.Errorf("retried %d events after giving up", n)
+	}
+	if n, err := store.PruneDelivered(ctx, now); err != nil || n != 2 {
+		t.Errorf("PruneDelivered = %d, %v, want the dead and the delivered event", n, err)
+	}
+}
+
+func TestRelayWithoutSinks(t *testing.T) {
+	store := memory.NewCommentStore()
+	svc := comment.NewService(store)
+
+	if _, err := svc.PostComment(ctx, comment.Comment{Slug: "post", Body: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	relay := &outbox.Relay{Store: store}
+	if n, err := relay.RunOnce(ctx); err != nil || n != 0 {
+		t.Errorf("RunOnce = %d, %v, want nothing claimed without sinks", n, err)
+	}
+
+	now := time.Now()
+	if n, err := store.PruneUndelivered(ctx, now.Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("PruneUndelivered = %d, %v, want the recent event kept", n, err)
+	}
+	events, err := store.ClaimEvents(ctx, now, now.Add(time.Minute), 10)
+	if err != nil || len(events) != 1 || events[0].Type != comment.EventCommentCreated {
+		t.Fatalf("ClaimEvents = %+v, %v, want the created event", events, err)
+	}
+	if n, err := store.PruneUndelivered(ctx, now.Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("PruneUndelivered = %d, %v, want 1", n, err)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got http.Header
+	var body []byte
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	e, err := outbox.New(comment.EventCommentCreated, "7", map[string]string{"body": "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.ID = 42
+
+	sink := &outbox.WebhookSink{URL: srv.URL, Secret: "s3cret"}
+	if err := sink.Deliver(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("X-Event-ID") != "42" || got.Get("X-Event-Type") != comment.EventCommentCreated {
+		t.Errorf("headers = %v", got)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); got.Get("X-Signature") != want {
+		t.Errorf("X-Signature = %q, want %q", got.Get("X-Signature"), want)
+	}
+
+	var sent outbox.Event
+	if err := json.Unmarshal(body, &sent); err != nil || sent.ID != 42 || sent.Key != "7" || string(sent.Payload) != `{"body":"hi"}` {
+		t.Errorf("body = %s, %v", body, err)
+	}
+
+	status = http.StatusBadGateway
+	if err := sink.Deliver(ctx, e); err == nil {
+		t.Error("Deliver succeeded on a 502")
+	}
+}
@@ -0,0 +1,30 @@
+// Package ratelimit throttles clients per key using a pluggable Store.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit allows Requests requests per Window.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Result is the outcome of a single Allow call.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the client is back to its full allowance.
+	Reset time.Duration
+	// RetryAfter is how long a rejected client should wait before retrying.
+	RetryAfter time.Duration
+}
+
+// Store records usage per key. Implementations must be safe for concurrent
+// use; shared stores let replicas enforce a single limit between them.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
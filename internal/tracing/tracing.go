@@ -0,0 +1,84 @@
+// Package tracing sets up OpenTelemetry tracing for the service: a tracer
+// provider that batches spans to an OTLP collector, stdout or a file, and
+// W3C Trace Context propagation so traces join those of other services.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans this service starts itself.
+const instrumentationName = "github.com/dvl-mukesh/go-workshop"
+
+// propagator reads the traceparent and tracestate headers.
+var propagator = propagation.TraceContext{}
+
+// Tracer starts spans and hands finished ones to an exporter in batches, so
+// request handling never waits on the tracing backend.
+type Tracer struct {
+	trace.Tracer
+	provider *sdktrace.TracerProvider
+}
+
+// NewTracer returns a tracer exporting through exporter. sampleRate is the
+// fraction of new traces to record, between 0 and 1; spans with a parent
+// follow the parent's sampling decision. Shutdown must be called before exit
+// to flush buffered spans.
+func NewTracer(exporter sdktrace.SpanExporter, serviceName string, sampleRate float64) *Tracer {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRate))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	return &Tracer{
+		Tracer:   provider.Tracer(instrumentationName),
+		provider: provider,
+	}
+}
+
+// Extract returns ctx carrying the caller's span context from the W3C Trace
+// Context headers in h, if they are valid, as the parent for the next span
+// started.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(h))
+}
+
+// RecordError marks span as failed with err. It does nothing if err is nil.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Hook traces each operation of a service as a child span named
+// component/operation. It matches comment.Hook.
+func (t *Tracer) Hook(component string) func(ctx context.Context, op string) (context.Context, func(error)) {
+	return func(ctx context.Context, op string) (context.Context, func(error)) {
+		ctx, span := t.Start(ctx, component+"/"+op, trace.WithAttributes(
+			attribute.String("code.namespace", component),
+			attribute.String("code.function", op),
+		))
+		return ctx, func(err error) {
+			RecordError(span, err)
+			span.End()
+		}
+	}
+}
+
+// Shutdown flushes buffered spans and stops the exporter.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
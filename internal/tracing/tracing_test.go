@@ -0,0 +1,104 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dvl-mukesh/go-workshop/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestExtract(t *testing.T) {
+	ctx := tracing.Extract(context.Background(), http.Header{"Traceparent": {traceparent}})
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsRemote() || !sc.IsSampled() || sc.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("unexpected span context %+v", sc)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+	} {
+		ctx := tracing.Extract(context.Background(), http.Header{"Traceparent": {bad}})
+		if trace.SpanContextFromContext(ctx).IsValid() {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestOTLPExport(t *testing.T) {
+	var got coltracepb.ExportTraceServiceRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if err := proto.Unmarshal(body, &got); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer srv.Close()
+
+	exporter, err := tracing.NewOTLPExporter(context.Background(), srv.URL+"/v1/traces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := tracing.NewTracer(exporter, "test", 0)
+
+	// A sampled caller is followed even though new traces are not sampled.
+	ctx := tracing.Extract(context.Background(), http.Header{"Traceparent": {traceparent}})
+	ctx, server := tracer.Start(ctx, "GET /v1/api/comment", trace.WithSpanKind(trace.SpanKindServer))
+	_, end := tracer.Hook("comment.Service")(ctx, "ListComments")
+	end(errors.New("boom"))
+	server.End()
+
+	_, unsampled := tracer.Start(context.Background(), "dropped")
+	unsampled.End()
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	rs := got.GetResourceSpans()
+	if len(rs) != 1 || len(rs[0].GetScopeSpans()) != 1 {
+		t.Fatalf("unexpected payload %v", &got)
+	}
+	if name := rs[0].GetResource().GetAttributes()[0]; name.GetKey() != "service.name" || name.GetValue().GetStringValue() != "test" {
+		t.Errorf("resource = %v, want the service name", rs[0].GetResource())
+	}
+	spans := rs[0].GetScopeSpans()[0].GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	c, s := spans[0], spans[1]
+	parent := trace.SpanContextFromContext(tracing.Extract(context.Background(), http.Header{"Traceparent": {traceparent}}))
+	if trace.TraceID(s.GetTraceId()) != parent.TraceID() || trace.TraceID(c.GetTraceId()) != parent.TraceID() {
+		t.Error("spans did not join the caller's trace")
+	}
+	if trace.SpanID(s.GetParentSpanId()) != parent.SpanID() || string(c.GetParentSpanId()) != string(s.GetSpanId()) {
+		t.Error("unexpected span parents")
+	}
+	if c.GetName() != "comment.Service/ListComments" || c.GetStatus().GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("unexpected child span %v", c)
+	}
+	if s.GetKind() != tracepb.Span_SPAN_KIND_SERVER {
+		t.Errorf("unexpected server span kind %v", s.GetKind())
+	}
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+)
+
+var (
+	MsgInvalidSlug = "Invalid slug"
+)
+
+func (h *Handler) GetArticleComments(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+
+	if slug == "" {
+		writeError(w, r, badRequest("invalid_slug", MsgInvalidSlug, nil))
+		return
+	}
+
+	opts, err := parseListOptions(r.URL.Query())
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	comments, err := h.Service.GetArticleComments(r.Context(), slug, opts)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgFetchSuccess,
+		Data:   comments,
+	})
+}
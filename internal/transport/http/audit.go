@@ -0,0 +1,125 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+)
+
+var MsgAuditSuccess = "Audit Log Fetched Successfully"
+
+// parseAuditOptions reads the audit log filters: comment_id, actor, action,
+// since, until, limit and cursor.
+func parseAuditOptions(q url.Values) (comment.AuditOptions, error) {
+	opts := comment.AuditOptions{
+		Actor:  q.Get("actor"),
+		Action: comment.AuditAction(q.Get("action")),
+		Cursor: q.Get("cursor"),
+	}
+
+	if v := q.Get("comment_id"); v != "" {
+		ID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return opts, badRequest("invalid_id", MsgInvalidId, err)
+		}
+		opts.CommentID = uint(ID)
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return opts, badRequest("invalid_limit", MsgInvalidLimit, err)
+		}
+		opts.Limit = limit
+	}
+
+	for param, dst := range map[string]*time.Time{
+		"since": &opts.Since,
+		"until": &opts.Until,
+	} {
+		if v := q.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return opts, badRequest("invalid_time", MsgInvalidTime, err)
+			}
+			*dst = t
+		}
+	}
+
+	return opts, nil
+}
+
+// GetAuditLog serves GET /api/audit, newest entries first.
+func (h *Handler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseAuditOptions(r.URL.Query())
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	page, err := h.Service.GetAuditLog(r.Context(), opts)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgAuditSuccess,
+		Data:   page,
+	})
+}
+
+// ExportAuditLog serves GET /api/audit/export, streaming the entries that
+// match the same filters as GetAuditLog as JSON Lines, oldest first.
+func (h *Handler) ExportAuditLog(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseAuditOptions(r.URL.Query())
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	out := &exportWriter{w: w}
+	err = h.Service.ExportAuditLog(r.Context(), opts, out)
+
+	switch {
+	case err == nil && !out.started:
+		// Nothing matched; still send an empty export.
+		out.start()
+	case err != nil && !out.started:
+		writeError(w, r, err)
+	case err != nil:
+		// The status line has gone out, so all we can do is cut the
+		// export short and log why.
+		slog.ErrorContext(r.Context(), "exporting audit log", "err", err)
+	}
+}
+
+// exportWriter sends the export headers before the first line is written,
+// so errors found before then still get a normal error response.
+type exportWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (e *exportWriter) start() {
+	e.started = true
+	e.w.Header().Set("Content-Type", "application/x-ndjson")
+	e.w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	e.w.WriteHeader(http.StatusOK)
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.start()
+	}
+	return e.w.Write(p)
+}
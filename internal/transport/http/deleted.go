@@ -0,0 +1,83 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+)
+
+var (
+	MsgDeletedSuccess = "Deleted Comments Fetched Successfully"
+	MsgRestoreSuccess = "Comment Restored Successfully"
+	MsgPurgeSuccess   = "Comment Purged Successfully"
+)
+
+// ListDeletedComments serves GET /api/comment/deleted. It takes the listing
+// parameters, and sort may also be deleted_at.
+func (h *Handler) ListDeletedComments(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r.URL.Query())
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	page, err := h.Service.ListDeletedComments(r.Context(), opts)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgDeletedSuccess,
+		Data:   page,
+	})
+}
+
+// RestoreComment serves POST /api/comment/{id}/restore.
+func (h *Handler) RestoreComment(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	restored, err := h.Service.RestoreComment(r.Context(), uint(i))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgRestoreSuccess,
+		Data:   restored,
+	})
+}
+
+// PurgeComment serves POST /api/comment/{id}/purge.
+func (h *Handler) PurgeComment(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	if err := h.Service.PurgeComment(r.Context(), uint(i)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgPurgeSuccess,
+	})
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/request"
+	"github.com/dvl-mukesh/go-workshop/internal/validate"
+)
+
+// ProblemContentType is the RFC 7807 media type. Clients that list it in
+// Accept get errors as problem details instead of the usual envelope.
+const ProblemContentType = "application/problem+json"
+
+// requestError is a failure detected by the transport itself, such as a
+// malformed ID or body, before the service is involved.
+type requestError struct {
+	status  int
+	code    string
+	message string
+	err     error
+	// fields lists per-field validation failures, if any.
+	fields validate.Errors
+}
+
+func (e *requestError) Error() string {
+	if e.err != nil {
+		return e.message + ": " + e.err.Error()
+	}
+	return e.message
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+func badRequest(code, message string, err error) *requestError {
+	return &requestError{status: http.StatusBadRequest, code: code, message: message, err: err}
+}
+
+func unprocessable(code, message string, err error) *requestError {
+	return &requestError{status: http.StatusUnprocessableEntity, code: code, message: message, err: err}
+}
+
+// errorResponse is the error envelope: dvlutil.Response plus a machine
+// readable code.
+type errorResponse struct {
+	Status string          `json:"status"`
+	Msg    string          `json:"msg"`
+	Code   string          `json:"code"`
+	Errors validate.Errors `json:"errors,omitempty"`
+}
+
+// problem is an RFC 7807 problem details document.
+type problem struct {
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Status    int             `json:"status"`
+	Detail    string          `json:"detail"`
+	Instance  string          `json:"instance,omitempty"`
+	Code      string          `json:"code"`
+	RequestID string          `json:"request_id,omitempty"`
+	Errors    validate.Errors `json:"errors,omitempty"`
+}
+
+var kindStatus = map[comment.Kind]int{
+	comment.KindNotFound:   http.StatusNotFound,
+	comment.KindConflict:   http.StatusConflict,
+	comment.KindValidation: http.StatusUnprocessableEntity,
+	comment.KindForbidden:  http.StatusForbidden,
+	comment.KindInternal:   http.StatusInternalServerError,
+}
+
+// errorDetails works out the status, code, client-facing message and field
+// errors for err. Internal errors get a generic message so causes never leak.
+func errorDetails(err error) (int, string, string, validate.Errors) {
+	var re *requestError
+	if errors.As(err, &re) {
+		return re.status, re.code, re.message, re.fields
+	}
+
+	e := comment.AsError(err)
+	if e.Kind == comment.KindInternal {
+		return http.StatusInternalServerError, "internal", MsgInternalServerErr, nil
+	}
+	return kindStatus[e.Kind], e.Code, e.Message, nil
+}
+
+// writeError reports err to the client, as problem+json when asked for, and
+// logs it.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code, msg, fields := errorDetails(err)
+	logError(r, status, err)
+
+	if !wantsProblem(r) {
+		dvlutil.WriteJSON(w, status, errorResponse{
+			Status: dvlutil.StatusCodeNotOK,
+			Msg:    msg,
+			Code:   code,
+			Errors: fields,
+		})
+		return
+	}
+
+	// RequestURI still has the /v1 prefix that routing stripped from URL.
+	instance, _, _ := strings.Cut(r.RequestURI, "?")
+	body, _ := json.Marshal(problem{
+		Type:      "/problems/" + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    msg,
+		Instance:  instance,
+		Code:      code,
+		RequestID: request.IDFromContext(r.Context()),
+		Errors:    fields,
+	})
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// logError records why a request failed, at warn for client errors and at
+// error for server errors.
+func logError(r *http.Request, status int, err error) {
+	level := slog.LevelWarn
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.Log(r.Context(), level, "request failed", "status", status, "err", err)
+}
+
+func wantsProblem(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == ProblemContentType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	transportHTTP "github.com/dvl-mukesh/go-workshop/internal/transport/http"
+)
+
+func TestErrorStatus(t *testing.T) {
+	s := newServer(t, comment.WithPolicy(&comment.Policy{}))
+	c := s.post(comment.Comment{Slug: "post", Author: "alice", Body: "first"})
+	bob := &auth.Principal{Subject: "bob"}
+
+	report := request{method: http.MethodPost, path: commentPath(c.ID) + "/report", body: `{"reason": "spam"}`, as: bob}
+	if w := s.do(report); w.Code != http.StatusOK {
+		t.Fatalf("first report: got %d: %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name   string
+		req    request
+		status int
+		code   string
+	}{
+		{"missing comment", request{method: http.MethodGet, path: commentPath(999)}, http.StatusNotFound, "comment_not_found"},
+		{"invalid ID", request{method: http.MethodGet, path: "/api/comment/abc"}, http.StatusBadRequest, "invalid_id"},
+		{"duplicate report", report, http.StatusConflict, "already_reported"},
+		{"invalid cursor", request{method: http.MethodGet, path: "/api/comment?cursor=bogus"}, http.StatusUnprocessableEntity, "invalid_cursor"},
+		{"someone else's comment", request{
+			method: http.MethodPut,
+			path:   commentPath(c.ID),
+			body:   `{"slug": "post", "body": "mine now"}`,
+			as:     bob,
+		}, http.StatusForbidden, "forbidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.do(tt.req)
+			body := expectError(t, w, tt.status, tt.code)
+			if body.Status != dvlutil.StatusCodeNotOK || body.Msg == "" {
+				t.Errorf("envelope = %+v, want a %s status and a message", body, dvlutil.StatusCodeNotOK)
+			}
+			if ct := w.Header().Get("Content-Type"); ct == transportHTTP.ProblemContentType {
+				t.Errorf("Content-Type = %s without asking for problem details", ct)
+			}
+		})
+	}
+}
+
+func TestProblemDetails(t *testing.T) {
+	s := newServer(t)
+
+	w := s.do(request{
+		method: http.MethodGet,
+		path:   commentPath(999) + "?x=1",
+		header: http.Header{"Accept": {"application/json, " + transportHTTP.ProblemContentType + "; q=0.9"}},
+	})
+	if ct := w.Header().Get("Content-Type"); ct != transportHTTP.ProblemContentType {
+		t.Errorf("Content-Type = %q, want %s", ct, transportHTTP.ProblemContentType)
+	}
+	body := expectError(t, w, http.StatusNotFound, "comment_not_found")
+	if body.Type != "/problems/comment_not_found" || body.Title != "Not Found" || body.Status != float64(http.StatusNotFound) ||
+		body.Detail != "comment not found" || body.Instance != "/v1/api/comment/999" {
+		t.Errorf("problem = %+v", body)
+	}
+
+	w = s.do(request{
+		method: http.MethodPost,
+		path:   "/api/comment",
+		body:   `{"slug": "Not A Slug", "body": ""}`,
+		header: http.Header{"Accept": {transportHTTP.ProblemContentType}},
+	})
+	body = expectError(t, w, http.StatusUnprocessableEntity, "validation_failed")
+	if len(body.Errors) == 0 || body.Title != "Unprocessable Entity" {
+		t.Errorf("problem = %+v, want the field errors", body)
+	}
+}
@@ -1,14 +1,14 @@
 package http
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
 	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/health"
 )
 
 var (
@@ -21,37 +21,99 @@ var (
 	MsgUpdateSuccess     = "Comment Updated Successfully"
 )
 
+const apiPrefix = "/v1"
+
 type Handler struct {
 	Router  *http.ServeMux
-	Service *comment.Service
+	Service comment.CommentService
+	Health  *health.Registry
+	// Metrics, when set, is served on /metrics.
+	Metrics http.Handler
+	// MaxBodyBytes caps request bodies; DefaultMaxBodyBytes when zero.
+	MaxBodyBytes int64
 	http.Server
+
+	api *http.ServeMux
 }
 
-func NewHandler(service *comment.Service) *Handler {
+func NewHandler(service comment.CommentService, checks *health.Registry) *Handler {
 	return &Handler{
 		Service: service,
+		Health:  checks,
 	}
 }
 
 func (h *Handler) SetupRoutes() {
-	log.Println("Setting up routes")
+	slog.Debug("Setting up routes")
 
 	h.Router = http.NewServeMux()
-	h.Router.HandleFunc("/api/health", healthHandler)
+	h.Router.HandleFunc("/api/health", h.healthHandler)
 	h.Router.HandleFunc("GET /api/comment", h.GetAllComments)
+	h.Router.HandleFunc("GET /api/comment/search", h.SearchComments)
+	h.Router.HandleFunc("GET /api/comment/deleted", h.ListDeletedComments)
 	h.Router.HandleFunc("GET /api/comment/{id}", h.GetComment)
 	h.Router.HandleFunc("POST /api/comment", h.PostComment)
 	h.Router.HandleFunc("PUT /api/comment/{id}", h.PutComment)
-	// h.Router.HandleFunc("PATCH /api/comment/{id}", h.PatchComment)
+	h.Router.HandleFunc("PATCH /api/comment/{id}", h.PatchComment)
 	h.Router.HandleFunc("DELETE /api/comment/{id}", h.DeleteComment)
+	h.Router.HandleFunc("GET /api/comment/{id}/thread", h.GetThread)
+	h.Router.HandleFunc("POST /api/comment/{id}/reply", h.ReplyToComment)
+	h.Router.HandleFunc("GET /api/article/{slug}/comments", h.GetArticleComments)
+	h.Router.HandleFunc("POST /api/comment/{id}/report", h.ReportComment)
+	h.Router.HandleFunc("GET /api/comment/{id}/reports", h.GetReports)
+	h.Router.HandleFunc("GET /api/comment/{id}/decisions", h.GetDecisions)
+	h.Router.HandleFunc("POST /api/comment/{id}/approve", h.ApproveComment)
+	h.Router.HandleFunc("POST /api/comment/{id}/reject", h.RejectComment)
+	h.Router.HandleFunc("GET /api/moderation/queue", h.ModerationQueue)
+	h.Router.HandleFunc("PUT /api/comment/{id}/vote", h.PutVote)
+	h.Router.HandleFunc("DELETE /api/comment/{id}/vote", h.DeleteVote)
+	h.Router.HandleFunc("PUT /api/comment/{id}/reactions/{kind}", h.PutReaction)
+	h.Router.HandleFunc("DELETE /api/comment/{id}/reactions/{kind}", h.DeleteReaction)
+	h.Router.HandleFunc("GET /api/comment/{id}/revisions", h.GetRevisions)
+	h.Router.HandleFunc("GET /api/comment/{id}/revisions/diff", h.DiffRevisions)
+	h.Router.HandleFunc("POST /api/comment/{id}/restore", h.RestoreComment)
+	h.Router.HandleFunc("POST /api/comment/{id}/purge", h.PurgeComment)
+	h.Router.HandleFunc("GET /api/audit", h.GetAuditLog)
+	h.Router.HandleFunc("GET /api/audit/export", h.ExportAuditLog)
 
 	v1 := http.NewServeMux()
-	v1.Handle("/v1/", http.StripPrefix("/v1", h.Router))
+	v1.Handle(apiPrefix+"/", http.StripPrefix(apiPrefix, h.Router))
+	v1.HandleFunc("GET /livez", h.Livez)
+	v1.HandleFunc("GET /readyz", h.Readyz)
+	v1.HandleFunc("GET /healthz", h.Healthz)
+	if h.Metrics != nil {
+		v1.Handle("GET /metrics", h.Metrics)
+	}
+	h.api = h.Router
 	h.Router = v1
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, "I am alive!")
+// RoutePattern returns the path pattern of the route that serves r, such as
+// "/v1/api/comment/{id}", or "" when no route matches.
+func (h *Handler) RoutePattern(r *http.Request) string {
+	_, pattern := h.Router.Handler(r)
+	if pattern == apiPrefix+"/" {
+		r2 := new(http.Request)
+		*r2 = *r
+		u := *r.URL
+		u.Path = strings.TrimPrefix(u.Path, apiPrefix)
+		u.RawPath = ""
+		r2.URL = &u
+
+		_, pattern = h.api.Handler(r2)
+		if pattern == "" {
+			return ""
+		}
+		pattern = apiPrefix + stripMethod(pattern)
+	}
+	return stripMethod(pattern)
+}
+
+func stripMethod(pattern string) string {
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		return pattern[i+1:]
+	}
+	return pattern
 }
 
 func (h *Handler) GetComment(w http.ResponseWriter, r *http.Request) {
@@ -60,22 +122,14 @@ func (h *Handler) GetComment(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 
-		dvlutil.WriteJSON(w, http.StatusBadRequest, dvlutil.Response{
-			Status: dvlutil.StatusCodeNotOK,
-			Msg:    MsgInvalidId,
-		})
-		log.Println(err)
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
 		return
 	}
 
-	comments, err := h.Service.GetComment(uint(i))
+	comments, err := h.Service.GetComment(r.Context(), uint(i))
 
 	if err != nil {
-		dvlutil.WriteJSON(w, http.StatusBadRequest, dvlutil.Response{
-			Status: dvlutil.StatusCodeNotOK,
-			Msg:    MsgInternalServerErr,
-		})
-		log.Println(err)
+		writeError(w, r, err)
 		return
 	}
 
@@ -90,24 +144,20 @@ func (h *Handler) GetComment(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) PostComment(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	var comment comment.Comment
-	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
-		dvlutil.WriteJSON(w, http.StatusBadRequest, dvlutil.Response{
-			Status: dvlutil.StatusCodeNotOK,
-			Msg:    MsgBadReq,
-		})
-		log.Println(err)
+	var req CreateCommentRequest
+	if err := h.decodeRequest(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := requireAuthor(r, req.Author); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	newComment, err := h.Service.PostComment(comment)
+	newComment, err := h.Service.PostComment(r.Context(), req.comment())
 
 	if err != nil {
-		dvlutil.WriteJSON(w, http.StatusBadRequest, dvlutil.Response{
-			Status: dvlutil.StatusCodeNotOK,
-			Msg:    MsgBadReq,
-		})
-		log.Println(err)
+		writeError(w, r, err)
 		return
 	}
 
@@ -122,36 +172,24 @@ func (h *Handler) PostComment(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) PutComment(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	var comment comment.Comment
-	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
-		dvlutil.WriteJSON(w, http.StatusBadRequest, dvlutil.Response{
-			Status: dvlutil.StatusCodeNotOK,
-			Msg:    MsgBadReq,
-		})
-		log.Println(err)
-		return
-	}
-
 	id := r.PathValue("id")
 	i, err := strconv.ParseUint(id, 10, 64)
 
 	if err != nil {
-		dvlutil.WriteJSON(w, http.StatusBadRequest, dvlutil.Response{
-			Status: dvlutil.StatusCodeNotOK,
-			Msg:    MsgInvalidId,
-		})
-		log.Println(err)
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	var req UpdateCommentRequest
+	if err := h.decodeRequest(w, r, &req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	newComment, err := h.Service.UpdateComment(uint(i), comment)
+	newComment, err := h.Service.UpdateComment(r.Context(), uint(i), req.comment(), req.Reason)
 
 	if err != nil {
-		dvlutil.WriteJSON(w, http.StatusBadRequest, dvlutil.Response{
-			Status: dvlutil.StatusCodeNotOK,
-			Msg:    MsgBadReq,
-		})
-		log.Println(err)
+		writeError(w, r, err)
 		return
 	}
 
@@ -162,28 +200,17 @@ func (h *Handler) PutComment(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// func (h *Handler) PatchComment(w http.ResponseWriter, r *http.Request) {
-
-// }
 func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	i, err := strconv.ParseUint(id, 10, 64)
 
 	if err != nil {
-		dvlutil.WriteJSON(w, http.StatusBadRequest, dvlutil.Response{
-			Status: dvlutil.StatusCodeNotOK,
-			Msg:    MsgInvalidId,
-		})
-		log.Println(err)
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
 		return
 	}
 
-	if err := h.Service.DeleteComment(uint(i)); err != nil {
-		dvlutil.WriteJSON(w, http.StatusBadRequest, dvlutil.Response{
-			Status: dvlutil.StatusCodeNotOK,
-			Msg:    MsgInternalServerErr,
-		})
-		log.Println(err)
+	if err := h.Service.DeleteComment(r.Context(), uint(i)); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -194,20 +221,23 @@ func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 
 }
 func (h *Handler) GetAllComments(w http.ResponseWriter, r *http.Request) {
-	comments, err := h.Service.GetAllComments()
+	opts, err := parseListOptions(r.URL.Query())
 
 	if err != nil {
-		dvlutil.WriteJSON(w, http.StatusBadRequest, dvlutil.Response{
-			Status: dvlutil.StatusCodeNotOK,
-			Msg:    MsgInternalServerErr,
-		})
-		log.Println(err)
+		writeError(w, r, err)
+		return
+	}
+
+	page, err := h.Service.ListComments(r.Context(), opts)
+
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
 		Status: dvlutil.StatusCodeOK,
 		Msg:    MsgFetchSuccess,
-		Data:   comments,
+		Data:   page,
 	})
 }
@@ -0,0 +1,125 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/database/memory"
+	"github.com/dvl-mukesh/go-workshop/internal/health"
+	transportHTTP "github.com/dvl-mukesh/go-workshop/internal/transport/http"
+)
+
+var ctx = context.Background()
+
+// server is a handler over an in-memory service, as it is routed in
+// production.
+type server struct {
+	t       *testing.T
+	handler *transportHTTP.Handler
+	svc     *comment.Service
+}
+
+func newServer(t *testing.T, opts ...comment.Option) *server {
+	t.Helper()
+
+	svc := comment.NewService(memory.NewCommentStore(), opts...)
+	h := transportHTTP.NewHandler(svc, health.NewRegistry())
+	h.SetupRoutes()
+	return &server{t: t, handler: h, svc: svc}
+}
+
+// post creates a comment straight through the service.
+func (s *server) post(c comment.Comment) comment.Comment {
+	s.t.Helper()
+
+	c, err := s.svc.PostComment(ctx, c)
+	if err != nil {
+		s.t.Fatalf("PostComment: %v", err)
+	}
+	return c
+}
+
+func commentPath(ID uint) string {
+	return "/api/comment/" + strconv.FormatUint(uint64(ID), 10)
+}
+
+// request is a call to the server; API paths get the version prefix. as,
+// when set, is the authenticated caller.
+type request struct {
+	method, path, body string
+	header             http.Header
+	as                 *auth.Principal
+}
+
+func (s *server) do(req request) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	path := req.path
+	if strings.HasPrefix(path, "/api/") {
+		path = "/v1" + path
+	}
+	r := httptest.NewRequest(req.method, path, strings.NewReader(req.body))
+	for k, v := range req.header {
+		r.Header[k] = v
+	}
+	if req.as != nil {
+		r = r.WithContext(auth.WithPrincipal(r.Context(), *req.as))
+	}
+	w := httptest.NewRecorder()
+	s.handler.Router.ServeHTTP(w, r)
+	return w
+}
+
+// errorBody is the error envelope, or a problem+json document.
+type errorBody struct {
+	// Status is a string in the envelope and a number in problems.
+	Status any    `json:"status"`
+	Msg    string `json:"msg"`
+	Code   string `json:"code"`
+	Errors []struct {
+		Field string `json:"field"`
+		Rule  string `json:"rule"`
+	} `json:"errors"`
+
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+}
+
+// expectError checks w is an error with the given status and code.
+func expectError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) errorBody {
+	t.Helper()
+
+	var body errorBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body, err)
+	}
+	if w.Code != status || body.Code != code {
+		t.Errorf("got %d %s, want %d %s: %s", w.Code, body.Code, status, code, w.Body)
+	}
+	return body
+}
+
+// commentData decodes the comment in a successful response.
+func commentData(t *testing.T, w *httptest.ResponseRecorder) comment.Comment {
+	t.Helper()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Data comment.Comment `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body, err)
+	}
+	return body.Data
+}
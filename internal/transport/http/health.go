@@ -0,0 +1,103 @@
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+	"github.com/dvl-mukesh/go-workshop/internal/health"
+)
+
+var (
+	MsgAlive        = "Alive"
+	MsgReady        = "Ready"
+	MsgNotReady     = "Not Ready"
+	MsgHealthy      = "Healthy"
+	MsgUnhealthy    = "Unhealthy"
+	MsgShuttingDown = "Server is shutting down"
+)
+
+// SetReady controls whether the server reports itself as able to take
+// traffic. It is cleared at the start of a graceful shutdown.
+func (h *Handler) SetReady(ready bool) {
+	h.Health.SetReady(ready)
+}
+
+func (h *Handler) healthHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.Health.Ready() {
+		dvlutil.WriteJSON(w, http.StatusServiceUnavailable, dvlutil.Response{
+			Status: dvlutil.StatusCodeNotOK,
+			Msg:    MsgShuttingDown,
+		})
+		return
+	}
+	fmt.Fprintf(w, "I am alive!")
+}
+
+// Livez only reports that the process is serving requests; it never checks
+// dependencies, so a database outage doesn't get the pod restarted.
+func (h *Handler) Livez(w http.ResponseWriter, r *http.Request) {
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgAlive,
+		Data:   health.Report{Status: health.StatusUp},
+	})
+}
+
+// Readyz reports whether this instance should receive traffic: it is not
+// shutting down and every registered dependency check passes.
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if !h.Health.Ready() {
+		dvlutil.WriteJSON(w, http.StatusServiceUnavailable, dvlutil.Response{
+			Status: dvlutil.StatusCodeNotOK,
+			Msg:    MsgShuttingDown,
+			Data:   health.Report{Status: health.StatusDown},
+		})
+		return
+	}
+
+	report := h.Health.Run(r.Context())
+	report.Checks = nil
+
+	if report.Status != health.StatusUp {
+		dvlutil.WriteJSON(w, http.StatusServiceUnavailable, dvlutil.Response{
+			Status: dvlutil.StatusCodeNotOK,
+			Msg:    MsgNotReady,
+			Data:   report,
+		})
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgReady,
+		Data:   report,
+	})
+}
+
+// Healthz runs every dependency check and returns the status, latency and
+// details of each. The endpoint is public, so raw errors are only logged.
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	report := h.Health.Run(r.Context())
+	for name, result := range report.Checks {
+		if result.Status != health.StatusUp {
+			slog.WarnContext(r.Context(), "health check failed", "check", name, "err", result.Error)
+		}
+	}
+
+	if report.Status != health.StatusUp {
+		dvlutil.WriteJSON(w, http.StatusServiceUnavailable, dvlutil.Response{
+			Status: dvlutil.StatusCodeNotOK,
+			Msg:    MsgUnhealthy,
+			Data:   report.Public(),
+		})
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgHealthy,
+		Data:   report.Public(),
+	})
+}
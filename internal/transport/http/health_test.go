@@ -0,0 +1,46 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dvl-mukesh/go-workshop/internal/health"
+)
+
+func TestHealthzHidesErrors(t *testing.T) {
+	s := newServer(t)
+	s.handler.Health.Register("database", health.CheckerFunc(func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"ping_latency_ms": 1.5, "migration_version": 12}, errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	}))
+	s.handler.Health.Register("cache", health.CheckerFunc(func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"driver": "memory"}, nil
+	}))
+
+	w := s.do(request{method: http.MethodGet, path: "/healthz"})
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d, want 503: %s", w.Code, w.Body)
+	}
+	for _, leak := range []string{"10.0.0.5", "refused"} {
+		if strings.Contains(w.Body.String(), leak) {
+			t.Errorf("response reveals %q: %s", leak, w.Body)
+		}
+	}
+
+	var body struct {
+		Data health.Report `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	database, cache := body.Data.Checks["database"], body.Data.Checks["cache"]
+	if body.Data.Status != health.StatusDown || database.Status != health.StatusDown || cache.Status != health.StatusUp {
+		t.Errorf("report = %+v, want the status of each check", body.Data)
+	}
+	if database.Details["ping_latency_ms"] != 1.5 || database.Details["migration_version"] != float64(12) || cache.Details["driver"] != "memory" {
+		t.Errorf("report = %+v, want the details of each check", body.Data)
+	}
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+)
+
+var (
+	MsgInvalidLimit = "Invalid limit"
+	MsgInvalidOrder = "Invalid order, expected asc or desc"
+	MsgInvalidTime  = "Invalid time, expected RFC3339"
+)
+
+// parseListOptions reads paging, filtering and sorting query parameters.
+func parseListOptions(q url.Values) (comment.ListOptions, error) {
+	opts := comment.ListOptions{
+		Cursor: q.Get("cursor"),
+		Slug:   q.Get("slug"),
+		Author: q.Get("author"),
+		SortBy: q.Get("sort"),
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return opts, badRequest("invalid_limit", MsgInvalidLimit, err)
+		}
+		opts.Limit = limit
+	}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return opts, badRequest("invalid_order", MsgInvalidOrder, nil)
+	}
+
+	for param, dst := range map[string]*time.Time{
+		"created_after":  &opts.CreatedAfter,
+		"created_before": &opts.CreatedBefore,
+	} {
+		if v := q.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return opts, badRequest("invalid_time", MsgInvalidTime, err)
+			}
+			*dst = t
+		}
+	}
+
+	return opts, nil
+}
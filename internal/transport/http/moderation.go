@@ -0,0 +1,177 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+)
+
+var (
+	MsgModerateSuccess  = "Comment Moderated Successfully"
+	MsgReportSuccess    = "Comment Reported Successfully"
+	MsgQueueSuccess     = "Moderation Queue Fetched Successfully"
+	MsgReportsSuccess   = "Reports Fetched Successfully"
+	MsgDecisionsSuccess = "Filter Decisions Fetched Successfully"
+)
+
+// ApproveComment serves POST /api/comment/{id}/approve.
+func (h *Handler) ApproveComment(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, comment.StatusApproved)
+}
+
+// RejectComment serves POST /api/comment/{id}/reject.
+func (h *Handler) RejectComment(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, comment.StatusRejected)
+}
+
+func (h *Handler) moderate(w http.ResponseWriter, r *http.Request, status comment.Status) {
+	defer r.Body.Close()
+
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	var req ModerationRequest
+	if r.ContentLength != 0 {
+		if err := h.decodeRequest(w, r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	moderated, err := h.Service.ModerateComment(r.Context(), uint(i), status, req.Reason)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgModerateSuccess,
+		Data:   moderated,
+	})
+}
+
+// ReportComment serves POST /api/comment/{id}/report.
+func (h *Handler) ReportComment(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	var req ReportRequest
+	if err := h.decodeRequest(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	report, err := h.Service.ReportComment(r.Context(), uint(i), req.Reason)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgReportSuccess,
+		Data:   report,
+	})
+}
+
+// GetReports serves GET /api/comment/{id}/reports.
+func (h *Handler) GetReports(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	reports, err := h.Service.GetReports(r.Context(), uint(i))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgReportsSuccess,
+		Data:   reports,
+	})
+}
+
+// ModerationQueue serves GET /api/moderation/queue. It takes the listing
+// parameters plus status, a comma separated list of states to include.
+func (h *Handler) ModerationQueue(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts, err := parseListOptions(q)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if v := q.Get("status"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			status, err := comment.ParseStatus(strings.TrimSpace(s))
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			opts.Statuses = append(opts.Statuses, status)
+		}
+	}
+
+	page, err := h.Service.ModerationQueue(r.Context(), opts)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgQueueSuccess,
+		Data:   page,
+	})
+}
+
+// GetDecisions serves GET /api/comment/{id}/decisions.
+func (h *Handler) GetDecisions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	decisions, err := h.Service.GetDecisions(r.Context(), uint(i))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgDecisionsSuccess,
+		Data:   decisions,
+	})
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+	"github.com/dvl-mukesh/go-workshop/internal/jsonpatch"
+)
+
+var (
+	MsgUnsupportedMediaType = "Unsupported Content-Type, expected application/merge-patch+json or application/json-patch+json"
+	MsgInvalidPatch         = "Invalid patch document"
+	MsgFieldNotPatchable    = "Patch touches a field that cannot be modified"
+	MsgPatchTestFailed      = "Patch test operation failed"
+)
+
+// patchableFields are the only comment fields a PATCH request may touch,
+// plus the reason recorded with the edit.
+var patchableFields = []string{"slug", "body", "author", "reason"}
+
+func (h *Handler) PatchComment(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != jsonpatch.MergePatchContentType && mediaType != jsonpatch.JSONPatchContentType {
+		w.Header().Set("Accept-Patch", jsonpatch.MergePatchContentType+", "+jsonpatch.JSONPatchContentType)
+		writeError(w, r, &requestError{status: http.StatusUnsupportedMediaType, code: "unsupported_media_type", message: MsgUnsupportedMediaType})
+		return
+	}
+
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	body, err := h.readBody(w, r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	existing, err := h.Service.GetComment(r.Context(), uint(i))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Patches apply to the same document PUT accepts, which deliberately
+	// leaves out ID, timestamps and thread placement.
+	doc, _ := json.Marshal(UpdateCommentRequest{
+		Slug:   existing.Slug,
+		Body:   existing.Body,
+		Author: existing.Author,
+	})
+
+	patched, err := applyPatch(mediaType, doc, body)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := validateRequest(&patched); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	newComment, err := h.Service.UpdateComment(r.Context(), uint(i), patched.comment(), patched.Reason)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgUpdateSuccess,
+		Data:   newComment,
+	})
+}
+
+// applyPatch applies a merge patch or JSON patch to doc after checking that
+// it only touches patchable fields.
+func applyPatch(mediaType string, doc, patch []byte) (UpdateCommentRequest, error) {
+	var result []byte
+
+	switch mediaType {
+	case jsonpatch.MergePatchContentType:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(patch, &fields); err != nil {
+			return UpdateCommentRequest{}, badRequest("invalid_patch", MsgInvalidPatch, err)
+		}
+		for field := range fields {
+			if !slices.Contains(patchableFields, field) {
+				return UpdateCommentRequest{}, unprocessable("field_not_patchable", MsgFieldNotPatchable, errors.New("field not patchable: "+field))
+			}
+		}
+
+		merged, err := jsonpatch.MergePatch(doc, patch)
+		if err != nil {
+			return UpdateCommentRequest{}, badRequest("invalid_patch", MsgInvalidPatch, err)
+		}
+		result = merged
+
+	case jsonpatch.JSONPatchContentType:
+		p, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return UpdateCommentRequest{}, badRequest("invalid_patch", MsgInvalidPatch, err)
+		}
+		for _, field := range p.Paths() {
+			if !slices.Contains(patchableFields, field) {
+				return UpdateCommentRequest{}, unprocessable("field_not_patchable", MsgFieldNotPatchable, errors.New("field not patchable: "+field))
+			}
+		}
+
+		applied, err := p.Apply(doc)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return UpdateCommentRequest{}, &requestError{status: http.StatusConflict, code: "patch_test_failed", message: MsgPatchTestFailed, err: err}
+		}
+		if err != nil {
+			return UpdateCommentRequest{}, unprocessable("invalid_patch", MsgInvalidPatch, err)
+		}
+		result = applied
+	}
+
+	// A removed field comes back as absent and decodes to its zero value,
+	// which is how clients clear a field.
+	var out UpdateCommentRequest
+	dec := json.NewDecoder(bytes.NewReader(result))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&out); err != nil {
+		return UpdateCommentRequest{}, unprocessable("invalid_patch", MsgInvalidPatch, err)
+	}
+	return out, nil
+}
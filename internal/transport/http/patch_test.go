@@ -0,0 +1,66 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/jsonpatch"
+)
+
+func TestPatchComment(t *testing.T) {
+	s := newServer(t)
+	c := s.post(comment.Comment{Slug: "post", Author: "alice", Body: "first"})
+
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		return s.do(request{
+			method: http.MethodPatch,
+			path:   commentPath(c.ID),
+			body:   body,
+			header: http.Header{"Content-Type": {contentType}},
+		})
+	}
+
+	got := commentData(t, patch(jsonpatch.MergePatchContentType, `{"body": "merged", "reason": "typo"}`))
+	if got.Body != "merged" || got.Slug != "post" || got.Author != "alice" || !got.Edited {
+		t.Errorf("merge patched = %+v, want only the body changed", got)
+	}
+
+	got = commentData(t, patch(jsonpatch.JSONPatchContentType,
+		`[{"op": "test", "path": "/body", "value": "merged"}, {"op": "copy", "from": "/slug", "path": "/body"}]`))
+	if got.Body != "post" || got.Author != "alice" {
+		t.Errorf("JSON patched = %+v, want the slug copied to the body", got)
+	}
+
+	tests := []struct {
+		name, contentType, body string
+		status                  int
+		code                    string
+	}{
+		{"merge patch of a protected field", jsonpatch.MergePatchContentType, `{"status": "approved"}`, http.StatusUnprocessableEntity, "field_not_patchable"},
+		{"merge patch of the ID", jsonpatch.MergePatchContentType, `{"ID": 7}`, http.StatusUnprocessableEntity, "field_not_patchable"},
+		{"JSON patch of a protected field", jsonpatch.JSONPatchContentType, `[{"op": "replace", "path": "/status", "value": "approved"}]`, http.StatusUnprocessableEntity, "field_not_patchable"},
+		{"move from a protected field", jsonpatch.JSONPatchContentType, `[{"op": "move", "from": "/status", "path": "/body"}]`, http.StatusUnprocessableEntity, "field_not_patchable"},
+		{"copy from a protected field", jsonpatch.JSONPatchContentType, `[{"op": "copy", "from": "/parent_id", "path": "/author"}]`, http.StatusUnprocessableEntity, "field_not_patchable"},
+		{"failed test", jsonpatch.JSONPatchContentType, `[{"op": "test", "path": "/body", "value": "merged"}]`, http.StatusConflict, "patch_test_failed"},
+		{"malformed patch", jsonpatch.MergePatchContentType, `{`, http.StatusBadRequest, "invalid_patch"},
+		{"cleared required field", jsonpatch.JSONPatchContentType, `[{"op": "remove", "path": "/body"}]`, http.StatusUnprocessableEntity, "validation_failed"},
+		{"plain JSON", "application/json", `{"body": "x"}`, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"no content type", "", `{"body": "x"}`, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := patch(tt.contentType, tt.body)
+			expectError(t, w, tt.status, tt.code)
+			if tt.status == http.StatusUnsupportedMediaType && w.Header().Get("Accept-Patch") == "" {
+				t.Error("415 without Accept-Patch")
+			}
+		})
+	}
+
+	after, err := s.svc.GetComment(ctx, c.ID)
+	if err != nil || after.Body != "post" || after.Author != "alice" || after.Status != comment.StatusApproved {
+		t.Errorf("comment after rejected patches = %+v, %v, want it untouched", after, err)
+	}
+}
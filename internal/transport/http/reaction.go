@@ -0,0 +1,104 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+)
+
+var (
+	MsgVoteSuccess    = "Vote Recorded Successfully"
+	MsgUnvoteSuccess  = "Vote Removed Successfully"
+	MsgReactSuccess   = "Reaction Added Successfully"
+	MsgUnreactSuccess = "Reaction Removed Successfully"
+)
+
+// PutVote serves PUT /api/comment/{id}/vote.
+func (h *Handler) PutVote(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	var req VoteRequest
+	if err := h.decodeRequest(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	voted, err := h.Service.Vote(r.Context(), uint(i), req.Value)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgVoteSuccess,
+		Data:   voted,
+	})
+}
+
+// DeleteVote serves DELETE /api/comment/{id}/vote.
+func (h *Handler) DeleteVote(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	unvoted, err := h.Service.Vote(r.Context(), uint(i), 0)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgUnvoteSuccess,
+		Data:   unvoted,
+	})
+}
+
+// PutReaction serves PUT /api/comment/{id}/reactions/{kind}.
+func (h *Handler) PutReaction(w http.ResponseWriter, r *http.Request) {
+	h.react(w, r, true, MsgReactSuccess)
+}
+
+// DeleteReaction serves DELETE /api/comment/{id}/reactions/{kind}.
+func (h *Handler) DeleteReaction(w http.ResponseWriter, r *http.Request) {
+	h.react(w, r, false, MsgUnreactSuccess)
+}
+
+func (h *Handler) react(w http.ResponseWriter, r *http.Request, add bool, msg string) {
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	reacted, err := h.Service.React(r.Context(), uint(i), r.PathValue("kind"), add)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    msg,
+		Data:   reacted,
+	})
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+	"github.com/dvl-mukesh/go-workshop/internal/validate"
+)
+
+// DefaultMaxBodyBytes bounds request bodies when Handler.MaxBodyBytes is
+// not set.
+const DefaultMaxBodyBytes = 64 << 10
+
+var (
+	MsgBodyTooLarge     = "Request body too large"
+	MsgUnknownField     = "Request body contains an unknown field"
+	MsgValidationFailed = "Validation failed"
+)
+
+// CreateCommentRequest is the body of POST /api/comment. Author is ignored
+// for authenticated callers, who always post as themselves.
+type CreateCommentRequest struct {
+	Slug     string `json:"slug" validate:"required,max=200,slug"`
+	Body     string `json:"body" validate:"required,max=10000,text"`
+	Author   string `json:"author" validate:"max=100,text"`
+	ParentID *uint  `json:"parent_id" validate:"min=1"`
+}
+
+func (req CreateCommentRequest) comment() comment.Comment {
+	return comment.Comment{
+		Slug:     req.Slug,
+		Body:     req.Body,
+		Author:   req.Author,
+		ParentID: req.ParentID,
+	}
+}
+
+// ReplyRequest is the body of POST /api/comment/{id}/reply. Replies are
+// posted on the parent's article.
+type ReplyRequest struct {
+	Body   string `json:"body" validate:"required,max=10000,text"`
+	Author string `json:"author" validate:"max=100,text"`
+}
+
+func (req ReplyRequest) comment() comment.Comment {
+	return comment.Comment{
+		Body:   req.Body,
+		Author: req.Author,
+	}
+}
+
+// UpdateCommentRequest is the body of PUT /api/comment/{id} and the
+// document PATCH requests are applied to. Reason is kept with the revision
+// the update creates.
+type UpdateCommentRequest struct {
+	Slug   string `json:"slug" validate:"required,max=200,slug"`
+	Body   string `json:"body" validate:"required,max=10000,text"`
+	Author string `json:"author" validate:"max=100,text"`
+	Reason string `json:"reason,omitempty" validate:"max=500,text"`
+}
+
+func (req UpdateCommentRequest) comment() comment.Comment {
+	return comment.Comment{
+		Slug:   req.Slug,
+		Body:   req.Body,
+		Author: req.Author,
+	}
+}
+
+func (h *Handler) maxBodyBytes() int64 {
+	if h.MaxBodyBytes > 0 {
+		return h.MaxBodyBytes
+	}
+	return DefaultMaxBodyBytes
+}
+
+// readBody reads the request body, refusing bodies over the size limit.
+func (h *Handler) readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodyBytes()))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, &requestError{
+				status:  http.StatusRequestEntityTooLarge,
+				code:    "body_too_large",
+				message: MsgBodyTooLarge,
+				err:     err,
+			}
+		}
+		return nil, badRequest("invalid_body", MsgBadReq, err)
+	}
+	return body, nil
+}
+
+// decodeRequest reads a JSON body into dst, rejecting oversized bodies,
+// unknown fields and trailing data, then validates it.
+func (h *Handler) decodeRequest(w http.ResponseWriter, r *http.Request, dst any) error {
+	body, err := h.readBody(w, r)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		if strings.HasPrefix(err.Error(), "json: unknown field ") {
+			return badRequest("unknown_field", MsgUnknownField, err)
+		}
+		return badRequest("invalid_body", MsgBadReq, err)
+	}
+	if dec.More() {
+		return badRequest("invalid_body", MsgBadReq, errors.New("unexpected data after JSON body"))
+	}
+
+	return validateRequest(dst)
+}
+
+// requireAuthor insists on an author for anonymous callers, for example
+// when authentication is disabled; authenticated callers post as themselves.
+func requireAuthor(r *http.Request, author string) error {
+	if _, ok := auth.PrincipalFromContext(r.Context()); ok || strings.TrimSpace(author) != "" {
+		return nil
+	}
+	fields := validate.Errors{{Field: "author", Rule: "required", Message: "is required"}}
+	return &requestError{
+		status:  http.StatusUnprocessableEntity,
+		code:    "validation_failed",
+		message: MsgValidationFailed,
+		err:     fields,
+		fields:  fields,
+	}
+}
+
+// validateRequest checks v against its validate tags and reports every
+// failing field.
+func validateRequest(v any) error {
+	err := validate.Struct(v)
+
+	var fields validate.Errors
+	if errors.As(err, &fields) {
+		return &requestError{
+			status:  http.StatusUnprocessableEntity,
+			code:    "validation_failed",
+			message: MsgValidationFailed,
+			err:     err,
+			fields:  fields,
+		}
+	}
+	return nil
+}
+
+// ModerationRequest is the optional body of the approve and reject
+// endpoints. Rejecting needs a reason.
+type ModerationRequest struct {
+	Reason string `json:"reason" validate:"max=500,text"`
+}
+
+// ReportRequest is the body of POST /api/comment/{id}/report.
+type ReportRequest struct {
+	Reason string `json:"reason" validate:"required,max=500,text"`
+}
+
+// VoteRequest is the body of PUT /api/comment/{id}/vote.
+type VoteRequest struct {
+	Value int `json:"value" validate:"required,min=-1,max=1"`
+}
@@ -0,0 +1,83 @@
+package http_test
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dvl-mukesh/go-workshop/internal/auth"
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	s := newServer(t)
+	s.handler.MaxBodyBytes = 256
+	c := s.post(comment.Comment{Slug: "post", Author: "alice", Body: "first"})
+
+	tests := []struct {
+		name   string
+		req    request
+		status int
+		code   string
+	}{
+		{"unknown field", request{method: http.MethodPost, path: "/api/comment",
+			body: `{"slug": "post", "body": "hi", "author": "bob", "status": "approved"}`}, http.StatusBadRequest, "unknown_field"},
+		{"unknown field on update", request{method: http.MethodPut, path: commentPath(c.ID),
+			body: `{"slug": "post", "body": "hi", "id": 9}`}, http.StatusBadRequest, "unknown_field"},
+		{"trailing data", request{method: http.MethodPost, path: "/api/comment",
+			body: `{"slug": "post", "body": "hi", "author": "bob"} {}`}, http.StatusBadRequest, "invalid_body"},
+		{"malformed JSON", request{method: http.MethodPost, path: "/api/comment",
+			body: `{"slug": `}, http.StatusBadRequest, "invalid_body"},
+		{"too large", request{method: http.MethodPost, path: "/api/comment",
+			body: `{"slug": "post", "author": "bob", "body": "` + strings.Repeat("a", 300) + `"}`}, http.StatusRequestEntityTooLarge, "body_too_large"},
+		{"too large reply", request{method: http.MethodPost, path: commentPath(c.ID) + "/reply",
+			body: `{"author": "bob", "body": "` + strings.Repeat("a", 300) + `"}`}, http.StatusRequestEntityTooLarge, "body_too_large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, s.do(tt.req), tt.status, tt.code)
+		})
+	}
+
+	if comments, _ := s.svc.GetAllComments(ctx); len(comments) != 1 {
+		t.Errorf("%d comments after rejected requests, want 1", len(comments))
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	s := newServer(t)
+
+	// Every failing field is reported, not just the first.
+	w := s.do(request{method: http.MethodPost, path: "/api/comment",
+		body: `{"slug": "Not A Slug", "body": "", "author": "` + strings.Repeat("a", 101) + `", "parent_id": 0}`})
+	body := expectError(t, w, http.StatusUnprocessableEntity, "validation_failed")
+	var got []string
+	for _, e := range body.Errors {
+		got = append(got, e.Field+":"+e.Rule)
+	}
+	for _, want := range []string{"slug:slug", "body:required", "author:max", "parent_id:min"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("errors = %v, want %s among them", got, want)
+		}
+	}
+
+	// Anonymous callers must name an author; authenticated ones post as
+	// themselves.
+	post := request{method: http.MethodPost, path: "/api/comment", body: `{"slug": "post", "body": "hi"}`}
+	body = expectError(t, s.do(post), http.StatusUnprocessableEntity, "validation_failed")
+	if len(body.Errors) != 1 || body.Errors[0].Field != "author" || body.Errors[0].Rule != "required" {
+		t.Errorf("errors = %+v, want author required", body.Errors)
+	}
+	post.as = &auth.Principal{Subject: "alice"}
+	if c := commentData(t, s.do(post)); c.Author != "alice" {
+		t.Errorf("author = %q, want the caller", c.Author)
+	}
+
+	w = s.do(request{method: http.MethodPut, path: commentPath(1),
+		body: `{"slug": "post", "body": "edited", "reason": "` + strings.Repeat("r", 501) + `"}`})
+	body = expectError(t, w, http.StatusUnprocessableEntity, "validation_failed")
+	if len(body.Errors) != 1 || body.Errors[0].Field != "reason" || body.Errors[0].Rule != "max" {
+		t.Errorf("errors = %+v, want reason too long", body.Errors)
+	}
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+)
+
+var (
+	MsgRevisionsSuccess = "Revisions Fetched Successfully"
+	MsgDiffSuccess      = "Revision Diff Fetched Successfully"
+	MsgInvalidRevision  = "Invalid Revision"
+)
+
+// GetRevisions serves GET /api/comment/{id}/revisions.
+func (h *Handler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	revisions, err := h.Service.GetRevisions(r.Context(), uint(i))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgRevisionsSuccess,
+		Data:   revisions,
+	})
+}
+
+// DiffRevisions serves GET /api/comment/{id}/revisions/diff?from=&to=. Both
+// revision numbers are optional and default to the latest edit.
+func (h *Handler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	var numbers [2]int
+	for n, name := range []string{"from", "to"} {
+		if v := r.URL.Query().Get(name); v != "" {
+			numbers[n], err = strconv.Atoi(v)
+			if err != nil || numbers[n] < 1 {
+				writeError(w, r, badRequest("invalid_revision", MsgInvalidRevision, err))
+				return
+			}
+		}
+	}
+
+	d, err := h.Service.DiffRevisions(r.Context(), uint(i), numbers[0], numbers[1])
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgDiffSuccess,
+		Data:   d,
+	})
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+	"github.com/dvl-mukesh/go-workshop/internal/comment"
+)
+
+var (
+	MsgSearchSuccess = "Comments Searched Successfully"
+)
+
+// SearchComments serves GET /api/comment/search?q=..., accepting the same
+// filters and paging parameters as the comment listing.
+func (h *Handler) SearchComments(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	list, err := parseListOptions(q)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	page, err := h.Service.SearchComments(r.Context(), comment.SearchOptions{
+		Query:         q.Get("q"),
+		Limit:         list.Limit,
+		Cursor:        list.Cursor,
+		Slug:          list.Slug,
+		Author:        list.Author,
+		CreatedAfter:  list.CreatedAfter,
+		CreatedBefore: list.CreatedBefore,
+	})
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgSearchSuccess,
+		Data:   page,
+	})
+}
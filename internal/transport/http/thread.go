@@ -0,0 +1,91 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Digivate-Labs-Pvt-Ltd/dvlutil"
+)
+
+var (
+	MsgReplySuccess  = "Reply Created Successfully"
+	MsgThreadSuccess = "Thread Fetched Successfully"
+	MsgInvalidDepth  = "Invalid max_depth"
+	MsgInvalidFormat = "Invalid format, expected tree or flat"
+)
+
+func (h *Handler) GetThread(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	maxDepth := 0
+	if v := r.URL.Query().Get("max_depth"); v != "" {
+		maxDepth, err = strconv.Atoi(v)
+		if err != nil || maxDepth < 0 {
+			writeError(w, r, badRequest("invalid_max_depth", MsgInvalidDepth, err))
+			return
+		}
+	}
+
+	var data any
+	switch r.URL.Query().Get("format") {
+	case "", "tree":
+		data, err = h.Service.GetThread(r.Context(), uint(i), maxDepth)
+	case "flat":
+		data, err = h.Service.GetThreadFlat(r.Context(), uint(i), maxDepth)
+	default:
+		writeError(w, r, badRequest("invalid_format", MsgInvalidFormat, nil))
+		return
+	}
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgThreadSuccess,
+		Data:   data,
+	})
+}
+
+func (h *Handler) ReplyToComment(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id := r.PathValue("id")
+	i, err := strconv.ParseUint(id, 10, 64)
+
+	if err != nil {
+		writeError(w, r, badRequest("invalid_id", MsgInvalidId, err))
+		return
+	}
+
+	var req ReplyRequest
+	if err := h.decodeRequest(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := requireAuthor(r, req.Author); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	newComment, err := h.Service.ReplyToComment(r.Context(), uint(i), req.comment())
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	dvlutil.WriteJSON(w, http.StatusOK, dvlutil.Response{
+		Status: dvlutil.StatusCodeOK,
+		Msg:    MsgReplySuccess,
+		Data:   newComment,
+	})
+}
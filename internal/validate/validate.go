@@ -0,0 +1,219 @@
+// Package validate checks structs against rules declared in `validate`
+// struct tags, for example:
+//
+//	Body string `json:"body" validate:"required,max=10000,text"`
+//
+// Supported rules are required, min=N and max=N (length in characters for
+// strings, value for integers), slug and text. Fields are reported by their
+// JSON name.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FieldError describes why one field failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors lists every field that failed validation.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+type rule struct {
+	name  string
+	param int
+}
+
+type field struct {
+	index []int
+	name  string
+	rules []rule
+}
+
+var cache sync.Map // reflect.Type -> []field
+
+// Struct validates v, a struct or pointer to struct. It returns Errors when
+// any rule fails and panics on malformed tags, which are programming errors.
+func Struct(v any) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		panic("validate: expected a struct, got " + rv.Kind().String())
+	}
+
+	var errs Errors
+	for _, f := range fieldsOf(rv.Type()) {
+		fv := rv.FieldByIndex(f.index)
+		for _, r := range f.rules {
+			if msg := check(r, fv); msg != "" {
+				errs = append(errs, FieldError{Field: f.name, Rule: r.name, Message: msg})
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := cache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	for _, sf := range reflect.VisibleFields(t) {
+		tag, ok := sf.Tag.Lookup("validate")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+
+		name := sf.Name
+		if j, _, _ := strings.Cut(sf.Tag.Get("json"), ","); j != "" && j != "-" {
+			name = j
+		}
+
+		f := field{index: sf.Index, name: name}
+		for _, spec := range strings.Split(tag, ",") {
+			f.rules = append(f.rules, parseRule(t, sf.Name, spec))
+		}
+		fields = append(fields, f)
+	}
+
+	cache.Store(t, fields)
+	return fields
+}
+
+func parseRule(t reflect.Type, fieldName, spec string) rule {
+	name, param, hasParam := strings.Cut(strings.TrimSpace(spec), "=")
+	r := rule{name: name}
+
+	switch name {
+	case "required", "slug", "text":
+		if hasParam {
+			panic(fmt.Sprintf("validate: %s.%s: rule %s takes no parameter", t, fieldName, name))
+		}
+	case "min", "max":
+		n, err := strconv.Atoi(param)
+		if !hasParam || err != nil {
+			panic(fmt.Sprintf("validate: %s.%s: rule %s needs an integer parameter", t, fieldName, name))
+		}
+		r.param = n
+	default:
+		panic(fmt.Sprintf("validate: %s.%s: unknown rule %q", t, fieldName, name))
+	}
+	return r
+}
+
+// check returns a message if v breaks r. Optional fields left unset (nil
+// pointers and empty strings) only fail required.
+func check(r rule, v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if r.name == "required" {
+				return "is required"
+			}
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return checkString(r, v.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return checkInt(r, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return checkInt(r, int64(v.Uint()))
+	}
+	panic("validate: unsupported field kind " + v.Kind().String())
+}
+
+func checkString(r rule, s string) string {
+	if r.name == "required" {
+		if strings.TrimSpace(s) == "" {
+			return "is required"
+		}
+		return ""
+	}
+	if s == "" {
+		return ""
+	}
+
+	switch r.name {
+	case "min":
+		if utf8.RuneCountInString(s) < r.param {
+			return fmt.Sprintf("must be at least %d characters", r.param)
+		}
+	case "max":
+		if utf8.RuneCountInString(s) > r.param {
+			return fmt.Sprintf("must be at most %d characters", r.param)
+		}
+	case "slug":
+		if !slugPattern.MatchString(s) {
+			return "must contain only lowercase letters, digits and single hyphens"
+		}
+	case "text":
+		return checkText(s)
+	}
+	return ""
+}
+
+// checkText rejects invalid UTF-8, the replacement character that JSON
+// decoding substitutes for it, and control characters other than line
+// breaks and tabs.
+func checkText(s string) string {
+	if !utf8.ValidString(s) {
+		return "must be valid UTF-8"
+	}
+	for _, c := range s {
+		if c == utf8.RuneError {
+			return "must be valid UTF-8"
+		}
+		if unicode.IsControl(c) && c != '\n' && c != '\r' && c != '\t' {
+			return "must not contain control characters"
+		}
+	}
+	return ""
+}
+
+func checkInt(r rule, n int64) string {
+	switch r.name {
+	case "required":
+		if n == 0 {
+			return "is required"
+		}
+	case "min":
+		if n < int64(r.param) {
+			return fmt.Sprintf("must be at least %d", r.param)
+		}
+	case "max":
+		if n > int64(r.param) {
+			return fmt.Sprintf("must be at most %d", r.param)
+		}
+	default:
+		panic("validate: rule " + r.name + " does not apply to integers")
+	}
+	return ""
+}
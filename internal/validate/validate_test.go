@@ -0,0 +1,78 @@
+package validate_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dvl-mukesh/go-workshop/internal/validate"
+)
+
+type payload struct {
+	Slug     string `json:"slug" validate:"required,max=20,slug"`
+	Body     string `json:"body" validate:"required,max=10,text"`
+	Author   string `json:"author" validate:"min=2"`
+	ParentID *uint  `json:"parent_id" validate:"min=1"`
+	Ignored  string `json:"ignored"`
+}
+
+func TestStruct(t *testing.T) {
+	zero := uint(0)
+
+	tests := []struct {
+		name string
+		in   payload
+		want map[string]string // field -> rule
+	}{
+		{"valid", payload{Slug: "my-post-1", Body: "hello"}, nil},
+		{"missing", payload{Body: "   "}, map[string]string{"slug": "required", "body": "required"}},
+		{"bad slug", payload{Slug: "My Post", Body: "x"}, map[string]string{"slug": "slug"}},
+		{"too long", payload{Slug: "a", Body: strings.Repeat("é", 11)}, map[string]string{"body": "max"}},
+		{"multibyte within limit", payload{Slug: "a", Body: strings.Repeat("é", 10)}, nil},
+		{"control char", payload{Slug: "a", Body: "a\x00b"}, map[string]string{"body": "text"}},
+		{"replacement char", payload{Slug: "a", Body: "a�b"}, map[string]string{"body": "text"}},
+		{"newlines allowed", payload{Slug: "a", Body: "a\r\n\tb"}, nil},
+		{"optional min", payload{Slug: "a", Body: "b", Author: "x"}, map[string]string{"author": "min"}},
+		{"pointer min", payload{Slug: "a", Body: "b", ParentID: &zero}, map[string]string{"parent_id": "min"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(&tt.in)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var errs validate.Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected validate.Errors, got %v", err)
+			}
+			got := map[string]string{}
+			for _, fe := range errs {
+				got[fe.Field] = fe.Rule
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for field, rule := range tt.want {
+				if got[field] != rule {
+					t.Errorf("%s: got rule %q, want %q", field, got[field], rule)
+				}
+			}
+		})
+	}
+}
+
+func TestMalformedTagPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	validate.Struct(struct {
+		Name string `validate:"max=ten"`
+	}{})
+}
@@ -10,9 +10,33 @@ import (
 	"github.com/go-resty/resty/v2"
 )
 
+const baseURL = "http://localhost:8080"
+
 func TestHealthEndpoint(t *testing.T) {
 	log.Println("Running E2E tests for health check endpoint")
 
 	client := resty.New()
-	resp, err := client.R().Get("http://localhost:8080/api/health")
+	resp, err := client.R().Get(baseURL + "/v1/api/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode() != 200 {
+		t.Errorf("got status %d, want 200", resp.StatusCode())
+	}
+}
+
+func TestProbeEndpoints(t *testing.T) {
+	client := resty.New()
+
+	for _, path := range []string{"/livez", "/readyz", "/healthz"} {
+		resp, err := client.R().Get(baseURL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode() != 200 {
+			t.Errorf("%s: got status %d, want 200, body %s", path, resp.StatusCode(), resp.Body())
+		}
+	}
 }